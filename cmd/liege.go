@@ -1,12 +1,24 @@
 package main
 
 import (
+	"gaelgirodon.fr/liege/internal/command"
 	"gaelgirodon.fr/liege/internal/console"
 	"gaelgirodon.fr/liege/internal/server"
+	"os"
 )
 
 // main is the application entrypoint.
 func main() {
+	// Run a subcommand (unless a directory with the same name is given as the root directory)
+	if len(os.Args) > 1 {
+		if cmd, found := command.Find(os.Args[1]); found && !isDir(os.Args[1]) {
+			if err := cmd.Run(os.Args[2:]); err != nil {
+				console.Logger.Fatalln("Error: " + err.Error())
+			}
+			return
+		}
+	}
+
 	// Parse command-line arguments
 	cfg, err := console.ParseArgs()
 	if err != nil {
@@ -25,3 +37,9 @@ func main() {
 		console.Logger.Fatalln("Error: " + err.Error())
 	}
 }
+
+// isDir indicates whether the given path is an existing directory.
+func isDir(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.IsDir()
+}
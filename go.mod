@@ -2,7 +2,10 @@ module gaelgirodon.fr/liege
 
 go 1.25
 
-require github.com/labstack/echo/v4 v4.15.0
+require (
+	github.com/labstack/echo/v4 v4.15.0
+	go.yaml.in/yaml/v3 v3.0.4
+)
 
 require (
 	github.com/labstack/gommon v0.4.2 // indirect
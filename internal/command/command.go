@@ -0,0 +1,67 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"gaelgirodon.fr/liege/internal/console"
+)
+
+// Command is an application subcommand.
+type Command struct {
+	// Name is the command name.
+	Name string
+	// Run runs the command with the given arguments.
+	Run func(args []string) error
+}
+
+// commands are the available subcommands.
+var commands = []Command{
+	{Name: "import", Run: runImport},
+	{Name: "export", Run: runExport},
+	{Name: "healthcheck", Run: runHealthcheck},
+	{Name: "routes", Run: runRoutes},
+	{Name: "lint", Run: runLint},
+}
+
+// Find returns the subcommand with the given name.
+func Find(name string) (Command, bool) {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+	}
+	return Command{}, false
+}
+
+// errUsage is returned when command arguments are invalid.
+var errUsage = errors.New("invalid arguments, run with -h for usage")
+
+// parseFlags parses command arguments using the given flag set
+// and checks the number of positional arguments.
+func parseFlags(fs *flag.FlagSet, usage string, args []string, nArgs int) error {
+	return parseFlagsRange(fs, usage, args, nArgs, nArgs)
+}
+
+// parseFlagsRange parses command arguments using the given flag set and
+// checks the number of positional arguments is in the given range.
+func parseFlagsRange(fs *flag.FlagSet, usage string, args []string, minArgs int, maxArgs int) error {
+	fs.Usage = func() {
+		println("Usage:\n  " + console.AppName + " " + usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() < minArgs || fs.NArg() > maxArgs {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// ignoreHelp ignores the error returned when the help message is requested.
+func ignoreHelp(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
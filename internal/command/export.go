@@ -0,0 +1,46 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/openapi"
+	"gaelgirodon.fr/liege/internal/server"
+	"os"
+)
+
+// runExport exports the routes built from a root directory to another format.
+func runExport(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch format := args[0]; format {
+	case "openapi":
+		fs := flag.NewFlagSet("export openapi", flag.ContinueOnError)
+		output := fs.String("o", "", "output `file` path (standard output by default)")
+		strict := fs.Bool("strict", false, "fail if any stub file cannot be loaded")
+		if err := parseFlags(fs, "export openapi [flags] <root-dir>", args[1:], 1); err != nil {
+			return ignoreHelp(err)
+		}
+		if err := console.ValidateRootDirPath(fs.Arg(0)); err != nil {
+			return err
+		}
+		routes, err := server.BuildRoutes(fs.Arg(0), *strict)
+		if err != nil {
+			return errors.New("unable to load stub files and build routes: " + err.Error())
+		}
+		doc := openapi.FromRoutes(routes, openapi.Info{Title: console.AppName + " stubs", Version: console.Version})
+		data, _ := json.MarshalIndent(doc, "", "  ")
+		if len(*output) == 0 {
+			_, err = os.Stdout.Write(append(data, '\n'))
+			return err
+		} else if err = os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+			return errors.New("unable to write the OpenAPI document")
+		}
+		console.Logger.Printf("Exported %d route(s) to %s\n", len(routes), *output)
+		return nil
+	default:
+		return errors.New("unknown export format '" + format + "'")
+	}
+}
@@ -0,0 +1,66 @@
+package command
+
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/model"
+	"net/http"
+	"os"
+	"time"
+)
+
+// runHealthcheck probes the local server and fails if it is not healthy (or ready).
+// The server address is resolved like the server does: from flags, environment
+// variables and the configuration file (in this order).
+func runHealthcheck(args []string) error {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	configFlag := fs.String("f", "", "path to the configuration `file` (default <root-dir>/"+console.ConfigFileName+")")
+	portFlag := fs.Uint("p", console.DefaultPort, "`port` the server listens on")
+	certFlag := fs.String("c", "", "path to the TLS `certificate` PEM file (probe using HTTPS if set with the key)")
+	keyFlag := fs.String("k", "", "path to the TLS private `key` PEM file")
+	mgmtPrefixFlag := fs.String("mgmt-prefix", model.DefaultManagementPrefix, "URL path `prefix` of management endpoints")
+	mgmtPortFlag := fs.Uint("mgmt-port", 0, "`port` of the separate listener for management endpoints")
+	probeFlag := fs.String("probe", "ready", "endpoint to probe: health (server is up) or ready (stubs are loaded)")
+	timeoutFlag := fs.Int("timeout", 3000, "probe `timeout` in ms")
+	if err := parseFlagsRange(fs, "healthcheck [flags] [<root-dir>]", args, 0, 1); err != nil {
+		return ignoreHelp(err)
+	}
+	if err := console.SetFlagsFromEnv(fs, map[string]string{"f": console.ConfigFileEnvVar,
+		"p": console.PortEnvVar, "c": console.CertEnvVar, "k": console.KeyEnvVar,
+		"mgmt-prefix": console.MgmtPrefixEnvVar, "mgmt-port": console.MgmtPortEnvVar}); err != nil {
+		return err
+	} else if *probeFlag != "health" && *probeFlag != "ready" {
+		return errors.New("invalid probe '" + *probeFlag + "'")
+	}
+	root := os.Getenv(console.RootEnvVar)
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	if _, err := console.ApplyConfigFile(fs, *configFlag, root); err != nil {
+		return err
+	}
+	config := model.Config{Port: uint16(*portFlag), Cert: *certFlag, Key: *keyFlag,
+		MgmtPrefix: *mgmtPrefixFlag, MgmtPort: uint16(*mgmtPortFlag)}
+	scheme, port := "http", config.Port
+	if config.HasTLS() {
+		scheme = "https"
+	}
+	if config.MgmtPort > 0 {
+		port = config.MgmtPort
+	}
+	client := &http.Client{Timeout: time.Duration(*timeoutFlag) * time.Millisecond,
+		// The local server certificate is not verified (it may not be valid for the loopback address)
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	res, err := client.Get(fmt.Sprintf("%s://127.0.0.1:%d%s/%s", scheme, port, config.ManagementPrefix(), *probeFlag))
+	if err != nil {
+		return errors.New("unable to reach the server: " + err.Error())
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s probe failed with status %d", *probeFlag, res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/importer"
+)
+
+// runImport imports stub files into a root directory from another format.
+func runImport(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	var report importer.Report
+	var err error
+	switch format := args[0]; format {
+	case "har":
+		fs := flag.NewFlagSet("import har", flag.ContinueOnError)
+		if err = parseFlags(fs, "import har <file.har> <root-dir>", args[1:], 2); err != nil {
+			return ignoreHelp(err)
+		}
+		report, err = importer.HAR(fs.Arg(0), fs.Arg(1))
+	case "openapi":
+		fs := flag.NewFlagSet("import openapi", flag.ContinueOnError)
+		opts := importer.OpenAPIOptions{}
+		fs.StringVar(&opts.DefaultCode, "default-code", "",
+			"status `code` of the response served by default (lowest success code by default)")
+		fs.StringVar(&opts.Examples, "examples", importer.FirstExample,
+			"examples naming `strategy`: "+importer.FirstExample+" (first example only) or "+
+				importer.QueryExample+" (all examples, selected with the "+importer.ExampleQueryParam+" query parameter)")
+		if err = parseFlags(fs, "import openapi [flags] <openapi.json> <root-dir>", args[1:], 2); err != nil {
+			return ignoreHelp(err)
+		}
+		report, err = importer.OpenAPI(fs.Arg(0), fs.Arg(1), opts)
+	default:
+		return errors.New("unknown import format '" + format + "'")
+	}
+	if err != nil {
+		return err
+	}
+	printReport(report)
+	return nil
+}
+
+// printReport prints an import report.
+func printReport(report importer.Report) {
+	for _, msg := range report.Skipped {
+		console.Logger.Println("Skipped " + msg)
+	}
+	for _, msg := range report.Duplicates {
+		console.Logger.Println("Skipped duplicate " + msg)
+	}
+	console.Logger.Printf("Imported %d stub file(s), skipped %d and %d duplicate(s)\n",
+		len(report.Written), len(report.Skipped), len(report.Duplicates))
+}
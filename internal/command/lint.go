@@ -0,0 +1,40 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/server"
+)
+
+// runLint reports issues in the stub files of a root directory
+// and fails if any of them is an error.
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	if err := parseFlags(fs, "lint <root-dir>", args, 1); err != nil {
+		return ignoreHelp(err)
+	} else if err = console.ValidateRootDirPath(fs.Arg(0)); err != nil {
+		return err
+	}
+	issues, err := server.Lint(fs.Arg(0))
+	if err != nil {
+		return errors.New("unable to load stub files and build routes: " + err.Error())
+	}
+	errs := 0
+	for _, issue := range issues {
+		if issue.Severity == server.LintError {
+			errs++
+		}
+		if len(issue.FilePath) > 0 {
+			console.Logger.Printf("%s: %s: %s\n", issue.Severity, issue.FilePath, issue.Message)
+		} else {
+			console.Logger.Printf("%s: %s\n", issue.Severity, issue.Message)
+		}
+	}
+	console.Logger.Printf("Found %d error(s), %d warning(s)\n", errs, len(issues)-errs)
+	if errs > 0 {
+		return fmt.Errorf("lint failed with %d error(s)", errs)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/server"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// runRoutes prints the routes built from a root directory as a table or JSON.
+func runRoutes(args []string) error {
+	fs := flag.NewFlagSet("routes", flag.ContinueOnError)
+	format := fs.String("format", "table", "output `format`: table or json")
+	if err := parseFlags(fs, "routes [flags] <root-dir>", args, 1); err != nil {
+		return ignoreHelp(err)
+	} else if *format != "table" && *format != "json" {
+		return errors.New("invalid format '" + *format + "'")
+	} else if err = console.ValidateRootDirPath(fs.Arg(0)); err != nil {
+		return err
+	}
+	routes, loadErrs, err := server.LoadRoutes(fs.Arg(0))
+	if err != nil {
+		return errors.New("unable to load stub files and build routes: " + err.Error())
+	}
+	if len(loadErrs) > 0 {
+		// Keep the standard output parseable
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %d file(s) cannot be loaded (run lint for details)\n", len(loadErrs))
+	}
+	if *format == "json" {
+		data, _ := json.MarshalIndent(routes, "", "  ")
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "METHOD\tPATH\tQUERY\tCODE\tCONTENT TYPE\tPROFILE\tFILE")
+	for _, route := range routes {
+		method, query := route.Method, make([]string, 0, len(route.QueryParams))
+		if len(method) == 0 {
+			method = "*"
+		}
+		for _, qp := range route.QueryParams {
+			if len(qp.Value) > 0 {
+				query = append(query, qp.Name+"="+qp.Value)
+			} else {
+				query = append(query, qp.Name)
+			}
+		}
+		_, _ = fmt.Fprintln(w, strings.Join([]string{method, route.Path, orDash(strings.Join(query, "&")),
+			strconv.Itoa(route.Code), orDash(route.ContentType), orDash(route.Profile), route.FilePath}, "\t"))
+	}
+	return w.Flush()
+}
+
+// orDash returns the given value or a dash if it is empty.
+func orDash(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+	return value
+}
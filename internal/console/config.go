@@ -0,0 +1,281 @@
+package console
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gaelgirodon.fr/liege/internal/model"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ConfigFileName is the name of the configuration file looked up in the root directory.
+	ConfigFileName = "liege.json"
+	// ConfigFileEnvVar is the name of the environment variable to set the path to the configuration file.
+	ConfigFileEnvVar = "LIEGE_CONFIG"
+)
+
+// configKeys maps configuration file keys to command-line flag names.
+var configKeys = map[string]string{
+	"mounts": "m", "layers": "layer", "port": "p", "cert": "c", "key": "k", "latency": "l", "rate_limit_key": "rate-limit-key",
+	"body_size": "body-size", "echo_headers": "echo-headers", "upstreams": "u",
+	"upstream_timeout": "upstream-timeout", "record": "record", "watch": "watch", "watch_poll": "watch-poll",
+	"diagnostics": "diagnostics", "strict": "strict",
+	"spec": "spec", "spec_mode": "spec-mode", "journal_size": "journal-size", "mgmt_prefix": "mgmt-prefix",
+	"mgmt_token": "mgmt-token", "mgmt_auth": "mgmt-auth", "mgmt_port": "mgmt-port", "mgmt_loopback": "mgmt-loopback",
+}
+
+// configPathKeys are the configuration file keys of paths (besides root)
+// resolved relative to the configuration file directory.
+var configPathKeys = map[string]bool{"cert": true, "key": true, "spec": true}
+
+// ConfigFile is a parsed configuration file.
+type ConfigFile struct {
+	// Path is the path to the configuration file.
+	Path string
+	// Root is the path to the root server directory (if set).
+	Root string
+	// Values are the flag values indexed by configuration key.
+	Values map[string]string
+	// Directories are the default options of stub files per directory.
+	Directories model.Directories
+}
+
+// directoryDefaults is the configuration of default options of stub files in a directory.
+type directoryDefaults struct {
+	// Latency is the default simulated response latency in ms.
+	Latency json.RawMessage `json:"latency"`
+	// RateLimit is the default rate limit (e.g. 10s).
+	RateLimit string `json:"rate_limit"`
+	// Headers are default response headers.
+	Headers map[string]string `json:"headers"`
+}
+
+// LoadConfigFile loads and validates a JSON configuration file.
+func LoadConfigFile(filePath string) (*ConfigFile, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml", ".toml":
+		return nil, errors.New("unsupported configuration file format " + filepath.Ext(filePath) +
+			" (only JSON is supported)")
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, errors.New("unable to read configuration file " + filePath)
+	}
+	var raw map[string]json.RawMessage
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.New("invalid configuration file " + filePath + ": " + err.Error())
+	}
+	file := &ConfigFile{Path: filePath, Values: make(map[string]string)}
+	for key, value := range raw {
+		switch {
+		case key == "directories":
+			file.Directories, err = parseDirectories(value)
+		case key == "root":
+			file.Root, err = configString(key, value)
+		case len(configKeys[key]) > 0:
+			file.Values[key], err = configString(key, value)
+		default:
+			err = errors.New("unknown configuration key '" + key + "'")
+		}
+		if err != nil {
+			return nil, errors.New("invalid configuration file " + filePath + ": " + err.Error())
+		}
+	}
+	// Resolve paths relative to the configuration file directory
+	dir := filepath.Dir(filePath)
+	resolve := func(p string) string {
+		if len(p) > 0 && !filepath.IsAbs(p) {
+			return filepath.Join(dir, p)
+		}
+		return p
+	}
+	file.Root = resolve(file.Root)
+	for key := range configPathKeys {
+		if value, exists := file.Values[key]; exists {
+			file.Values[key] = resolve(value)
+		}
+	}
+	if value, exists := file.Values["layers"]; exists {
+		layers := strings.Split(value, ",")
+		for i, layer := range layers {
+			layers[i] = resolve(strings.TrimSpace(layer))
+		}
+		file.Values["layers"] = strings.Join(layers, ",")
+	}
+	if value, exists := file.Values["mounts"]; exists {
+		mounts := strings.Split(value, ",")
+		for i, item := range mounts {
+			if mount, err := model.ParseMount(strings.TrimSpace(item)); err == nil {
+				mounts[i] = mount.Prefix + "=" + resolve(mount.Root)
+			}
+		}
+		file.Values["mounts"] = strings.Join(mounts, ",")
+	}
+	return file, nil
+}
+
+// configString converts a configuration value (string, number, boolean,
+// latency object or upstreams array) to a flag value.
+func configString(key string, value json.RawMessage) (string, error) {
+	if key == "latency" {
+		var latency *model.Latency
+		if err := json.Unmarshal(value, &latency); err == nil && latency != nil {
+			if latency.IsDisabledOrUndefined() {
+				return "-1", nil
+			}
+			return fmt.Sprintf("%d-%d", latency.Min, latency.Max), nil
+		}
+	} else if key == "mounts" {
+		var mounts []model.Mount
+		if err := json.Unmarshal(value, &mounts); err == nil {
+			values := make([]string, 0, len(mounts))
+			for _, mount := range mounts {
+				values = append(values, mount.Prefix+"="+mount.Root)
+			}
+			return strings.Join(values, ","), nil
+		}
+		var values []string
+		if err := json.Unmarshal(value, &values); err == nil {
+			return strings.Join(values, ","), nil
+		}
+	} else if key == "layers" {
+		var values []string
+		if err := json.Unmarshal(value, &values); err == nil {
+			return strings.Join(values, ","), nil
+		}
+	} else if key == "upstreams" {
+		var upstreams []model.Upstream
+		if err := json.Unmarshal(value, &upstreams); err == nil {
+			values := make([]string, 0, len(upstreams))
+			for _, upstream := range upstreams {
+				values = append(values, upstream.Prefix+"="+upstream.URL)
+			}
+			return strings.Join(values, ","), nil
+		}
+		var values []string
+		if err := json.Unmarshal(value, &values); err == nil {
+			return strings.Join(values, ","), nil
+		}
+	}
+	var v any
+	_ = json.Unmarshal(value, &v)
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", errors.New("invalid " + key + " value")
+}
+
+// parseDirectories parses and validates default options per directory.
+func parseDirectories(value json.RawMessage) (model.Directories, error) {
+	var raw map[string]directoryDefaults
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return nil, errors.New("invalid directories value")
+	}
+	dirs := make(model.Directories, len(raw))
+	for dir, d := range raw {
+		defaults := model.DirectoryDefaults{Latency: model.Latency{Min: -1, Max: -1}, Headers: d.Headers}
+		if len(d.Latency) > 0 {
+			latency, err := configString("latency", d.Latency)
+			if err == nil {
+				defaults.Latency, err = model.ParseLatency(latency, "")
+			}
+			if err != nil {
+				return nil, errors.New("invalid latency value for directory '" + dir + "'")
+			}
+		}
+		if len(d.RateLimit) > 0 {
+			var err error
+			if defaults.RateLimit, err = model.ParseRateLimit(d.RateLimit, ""); err != nil {
+				return nil, errors.New("invalid rate limit value for directory '" + dir + "'")
+			}
+		}
+		dirs[strings.Trim(path.Clean("/"+filepath.ToSlash(dir)), "/")] = defaults
+	}
+	return dirs, nil
+}
+
+// ApplyConfigFile loads the configuration file at the given path or, if not
+// set, in the root directory (if any) and sets flags of the flag set that are
+// not already set from its values. It returns the loaded file (nil if none).
+func ApplyConfigFile(fs *flag.FlagSet, filePath string, root string) (*ConfigFile, error) {
+	file, err := findConfigFile(filePath, root)
+	if err != nil || file == nil {
+		return nil, err
+	}
+	return file, file.apply(fs)
+}
+
+// apply sets flags of the flag set that are not already set from the
+// configuration file values (ignoring values of flags it does not define).
+func (file *ConfigFile) apply(fs *flag.FlagSet) error {
+	set := setFlags(fs)
+	keys := make([]string, 0, len(file.Values))
+	for key := range file.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if name := configKeys[key]; !set[name] && fs.Lookup(name) != nil {
+			if err := fs.Set(name, file.Values[key]); err != nil {
+				return errors.New("invalid " + key + " value in configuration file " + file.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// setFlags returns the names of flags of the flag set that have been set.
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// configSources returns the source of each configuration value (indexed by
+// configuration key) given the flags set on the command-line and from
+// environment variables (the others are set from the configuration file if
+// they have been set, or have their default value).
+func configSources(fs *flag.FlagSet, cli map[string]bool, env map[string]bool) map[string]string {
+	set := setFlags(fs)
+	sources := make(map[string]string, len(configKeys)+1)
+	for key, name := range configKeys {
+		switch {
+		case cli[name]:
+			sources[key] = model.SourceFlag
+		case env[name]:
+			sources[key] = model.SourceEnv
+		case set[name]:
+			sources[key] = model.SourceFile
+		default:
+			sources[key] = model.SourceDefault
+		}
+	}
+	return sources
+}
+
+// findConfigFile loads the configuration file at the given path or, if not
+// set, in the root directory (if any).
+func findConfigFile(filePath string, root string) (*ConfigFile, error) {
+	if len(filePath) == 0 && len(root) > 0 {
+		candidate := filepath.Join(root, ConfigFileName)
+		if stat, err := os.Stat(candidate); err == nil && stat.Mode().IsRegular() {
+			filePath = candidate
+		}
+	}
+	if len(filePath) == 0 {
+		return nil, nil
+	}
+	return LoadConfigFile(filePath)
+}
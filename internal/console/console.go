@@ -7,6 +7,7 @@ import (
 	"log"
 	"math"
 	"os"
+	"regexp"
 	"strings"
 )
 
@@ -25,6 +26,46 @@ const (
 	KeyEnvVar = "LIEGE_KEY"
 	// LatencyEnvVar is the name of the environment variable to set the global latency.
 	LatencyEnvVar = "LIEGE_LATENCY"
+	// RateLimitKeyEnvVar is the name of the environment variable to set the rate limit key header.
+	RateLimitKeyEnvVar = "LIEGE_RATE_LIMIT_KEY"
+	// BodySizeEnvVar is the name of the environment variable to set the maximum request body size sent back.
+	BodySizeEnvVar = "LIEGE_BODY_SIZE"
+	// EchoHeadersEnvVar is the name of the environment variable to send request headers back.
+	EchoHeadersEnvVar = "LIEGE_ECHO_HEADERS"
+	// MountsEnvVar is the name of the environment variable to set mounted root directories (comma-separated).
+	MountsEnvVar = "LIEGE_MOUNTS"
+	// LayersEnvVar is the name of the environment variable to set layer directories (comma-separated).
+	LayersEnvVar = "LIEGE_LAYERS"
+	// UpstreamEnvVar is the name of the environment variable to set upstream servers (comma-separated).
+	UpstreamEnvVar = "LIEGE_UPSTREAM"
+	// UpstreamTimeoutEnvVar is the name of the environment variable to set the upstream request timeout.
+	UpstreamTimeoutEnvVar = "LIEGE_UPSTREAM_TIMEOUT"
+	// RecordEnvVar is the name of the environment variable to enable the record mode.
+	RecordEnvVar = "LIEGE_RECORD"
+	// WatchEnvVar is the name of the environment variable to enable the watch mode.
+	WatchEnvVar = "LIEGE_WATCH"
+	// WatchPollEnvVar is the name of the environment variable to detect stub files changes by polling.
+	WatchPollEnvVar = "LIEGE_WATCH_POLL"
+	// DiagnosticsEnvVar is the name of the environment variable to enable diagnostics on unmatched requests.
+	DiagnosticsEnvVar = "LIEGE_DIAGNOSTICS"
+	// StrictEnvVar is the name of the environment variable to fail when stub files cannot be loaded.
+	StrictEnvVar = "LIEGE_STRICT"
+	// SpecEnvVar is the name of the environment variable to set the OpenAPI document to validate requests against.
+	SpecEnvVar = "LIEGE_SPEC"
+	// SpecModeEnvVar is the name of the environment variable to set the request validation mode.
+	SpecModeEnvVar = "LIEGE_SPEC_MODE"
+	// JournalSizeEnvVar is the name of the environment variable to set the request journal size.
+	JournalSizeEnvVar = "LIEGE_JOURNAL_SIZE"
+	// MgmtPrefixEnvVar is the name of the environment variable to set the management endpoints prefix.
+	MgmtPrefixEnvVar = "LIEGE_MGMT_PREFIX"
+	// MgmtTokenEnvVar is the name of the environment variable to set the management endpoints token.
+	MgmtTokenEnvVar = "LIEGE_MGMT_TOKEN"
+	// MgmtAuthEnvVar is the name of the environment variable to set the management endpoints basic auth.
+	MgmtAuthEnvVar = "LIEGE_MGMT_AUTH"
+	// MgmtPortEnvVar is the name of the environment variable to set the management listener port.
+	MgmtPortEnvVar = "LIEGE_MGMT_PORT"
+	// MgmtLoopbackEnvVar is the name of the environment variable to restrict management endpoints to loopback.
+	MgmtLoopbackEnvVar = "LIEGE_MGMT_LOOPBACK"
 	// DefaultPort is the default HTTP server port number.
 	DefaultPort = 3000
 )
@@ -40,52 +81,224 @@ func ParseArgs() (*model.Config, error) {
 		os.Exit(0)
 	}
 	// Parse args
+	configFlag := flag.String("f", "", "path to the configuration `file` (default <root-dir>/"+ConfigFileName+")")
 	portFlag := flag.Uint("p", DefaultPort, "`port` to listen on")
 	certFlag := flag.String("c", "", "path to the TLS `certificate` PEM file")
 	keyFlag := flag.String("k", "", "path to the TLS private `key` PEM file")
 	latencyFlag := flag.String("l", "0", "simulated response `latency` in ms")
+	rateLimitKeyFlag := flag.String("rate-limit-key", "", "request `header` identifying clients for rate limiting")
+	bodySizeFlag := flag.Int("body-size", model.DefaultRequestBodySize,
+		"maximum `size` in bytes of a request body sent back in a header (-1 to disable)")
+	echoHeadersFlag := flag.Bool("echo-headers", false, "send request headers back in response headers")
+	var mountsFlag mountsValue
+	flag.Var(&mountsFlag, "m", "root `directory` to mount under a URL path prefix ([<prefix>=]<dir>, repeatable)")
+	var layersFlag layersValue
+	flag.Var(&layersFlag, "layer", "`directory` overlaid on top of root directories (repeatable, lowest first)")
+	var upstreamsFlag upstreamsValue
+	flag.Var(&upstreamsFlag, "u", "`upstream` server to forward unmatched requests to ([<prefix>=]<url>, repeatable)")
+	recordFlag := flag.Bool("record", false, "record proxied exchanges as stub files in the root directory")
+	upstreamTimeoutFlag := flag.Int("upstream-timeout", model.DefaultUpstreamTimeout, "upstream request `timeout` in ms")
+	watchFlag := flag.Bool("watch", false, "rebuild routes when stub files change")
+	watchPollFlag := flag.Bool("watch-poll", false, "detect stub files changes by polling modification times instead of inotify")
+	diagnosticsFlag := flag.Bool("diagnostics", false, "respond to unmatched requests with the routes almost matching them")
+	strictFlag := flag.Bool("strict", false, "fail to start or refresh when any stub file cannot be loaded")
+	specFlag := flag.String("spec", "", "path to the OpenAPI `document` to validate requests against")
+	specModeFlag := flag.String("spec-mode", model.RejectViolations, "request validation `mode`: "+
+		model.RejectViolations+" (400 response) or "+model.ReportViolations+" (violations header and log)")
+	journalSizeFlag := flag.Int("journal-size", model.DefaultJournalSize,
+		"maximum `number` of requests kept in the journal (-1 to disable)")
+	mgmtPrefixFlag := flag.String("mgmt-prefix", model.DefaultManagementPrefix, "URL path `prefix` of management endpoints")
+	mgmtTokenFlag := flag.String("mgmt-token", "", "bearer `token` required to call management endpoints")
+	mgmtAuthFlag := flag.String("mgmt-auth", "", "basic auth `credentials` (user:password) required to call management endpoints")
+	mgmtPortFlag := flag.Uint("mgmt-port", 0, "`port` of a separate listener for management endpoints")
+	mgmtLoopbackFlag := flag.Bool("mgmt-loopback", false, "restrict management endpoints to the loopback interface")
 	flag.Usage = func() {
-		println("Usage:\n  " + AppName + " [flags] <root-dir>\n\nFlags:")
+		println("Usage:\n  " + AppName + " [flags] <root-dir>\n" +
+			"  " + AppName + " [flags] -m [<prefix>=]<dir> [-m ...] [<root-dir>]\n" +
+			"  " + AppName + " import har <file.har> <root-dir>\n" +
+			"  " + AppName + " import openapi [flags] <openapi.json> <root-dir>\n" +
+			"  " + AppName + " export openapi [flags] <root-dir>\n" +
+			"  " + AppName + " healthcheck [flags] [<root-dir>]\n\nFlags:")
 		flag.PrintDefaults()
 	}
-	args := strings.Join(os.Args, " ")
+	flag.Parse()
+	cliFlags := setFlags(flag.CommandLine)
 	// Default to environment variables
-	if !strings.Contains(args, "-p") && len(os.Getenv(PortEnvVar)) > 0 {
-		os.Args = append(os.Args, "-p="+os.Getenv(PortEnvVar))
-	}
-	if !strings.Contains(args, "-c") && len(os.Getenv(CertEnvVar)) > 0 {
-		os.Args = append(os.Args, "-c="+os.Getenv(CertEnvVar))
-	}
-	if !strings.Contains(args, "-k") && len(os.Getenv(KeyEnvVar)) > 0 {
-		os.Args = append(os.Args, "-k="+os.Getenv(KeyEnvVar))
+	if err := SetFlagsFromEnv(flag.CommandLine, map[string]string{
+		"f": ConfigFileEnvVar, "m": MountsEnvVar, "layer": LayersEnvVar,
+		"p": PortEnvVar, "c": CertEnvVar, "k": KeyEnvVar,
+		"l": LatencyEnvVar, "rate-limit-key": RateLimitKeyEnvVar, "body-size": BodySizeEnvVar,
+		"echo-headers": EchoHeadersEnvVar, "u": UpstreamEnvVar, "upstream-timeout": UpstreamTimeoutEnvVar,
+		"record": RecordEnvVar, "watch": WatchEnvVar, "watch-poll": WatchPollEnvVar,
+		"diagnostics": DiagnosticsEnvVar, "strict": StrictEnvVar, "spec": SpecEnvVar, "spec-mode": SpecModeEnvVar,
+		"journal-size": JournalSizeEnvVar, "mgmt-prefix": MgmtPrefixEnvVar, "mgmt-token": MgmtTokenEnvVar,
+		"mgmt-auth": MgmtAuthEnvVar, "mgmt-port": MgmtPortEnvVar, "mgmt-loopback": MgmtLoopbackEnvVar}); err != nil {
+		return nil, err
 	}
-	if !strings.Contains(args, "-l") && len(os.Getenv(LatencyEnvVar)) > 0 {
-		os.Args = append(os.Args, "-l="+os.Getenv(LatencyEnvVar))
+	envFlags := setFlags(flag.CommandLine)
+	for name := range cliFlags {
+		delete(envFlags, name)
 	}
-	flag.Parse()
-	// Validate root directory path
-	root := os.Getenv(RootEnvVar)
+	root, rootSource := os.Getenv(RootEnvVar), model.SourceEnv
 	if flag.NArg() > 0 {
-		root = flag.Arg(0)
+		root, rootSource = flag.Arg(0), model.SourceFlag
 	}
-	if err := ValidateRootDirPath(root); err != nil {
+	// Load the configuration file and default to its values
+	file, err := ApplyConfigFile(flag.CommandLine, *configFlag, root)
+	if err != nil {
 		return nil, err
+	} else if file != nil && len(root) == 0 {
+		root, rootSource = file.Root, model.SourceFile
+	}
+	sources := configSources(flag.CommandLine, cliFlags, envFlags)
+	sources["root"] = rootSource
+	// Validate root directory paths
+	if len(root) > 0 || len(mountsFlag) == 0 {
+		if err := ValidateRootDirPath(root); err != nil {
+			return nil, err
+		}
+	}
+	for _, mount := range mountsFlag {
+		if err := ValidateRootDirPath(mount.Root); err != nil {
+			return nil, errors.New("invalid mount " + mount.Prefix + ": " + err.Error())
+		}
+	}
+	for _, layer := range layersFlag {
+		if err := ValidateRootDirPath(layer); err != nil {
+			return nil, errors.New("invalid layer " + layer + ": " + err.Error())
+		}
+	}
+	if *recordFlag && len(root) == 0 {
+		return nil, errors.New("record mode requires a root directory to write stub files to")
 	}
 	// Validate port
 	if *portFlag < 80 || *portFlag > math.MaxUint16 {
 		return nil, errors.New("invalid port number")
 	}
+	// Validate management configuration
+	if err := ValidateManagementConfig(*mgmtPrefixFlag, *mgmtAuthFlag, *mgmtPortFlag, *portFlag); err != nil {
+		return nil, err
+	}
 	// Validate TLS configuration
 	if err := ValidateTLSConfig(*certFlag, *keyFlag); err != nil {
 		return nil, err
 	}
+	// Validate request body size
+	if *bodySizeFlag < -1 {
+		return nil, errors.New("invalid request body size")
+	}
+	// Validate journal size
+	if *journalSizeFlag < -1 {
+		return nil, errors.New("invalid journal size")
+	}
+	// Validate upstream timeout
+	if *upstreamTimeoutFlag <= 0 {
+		return nil, errors.New("invalid upstream timeout")
+	}
+	// Validate request validation configuration
+	if stat, err := os.Stat(*specFlag); len(*specFlag) > 0 && (err != nil || !stat.Mode().IsRegular()) {
+		return nil, errors.New("spec must be a path to a valid OpenAPI document")
+	} else if *specModeFlag != model.RejectViolations && *specModeFlag != model.ReportViolations {
+		return nil, errors.New("invalid request validation mode")
+	}
 	// Validate and parse latency
 	latency, err := model.ParseLatency(*latencyFlag, "")
 	if err != nil {
 		return nil, errors.New("invalid latency value")
 	}
-	return &model.Config{Root: root, Port: uint16(*portFlag),
-		Cert: *certFlag, Key: *keyFlag, Latency: latency}, nil
+	config := &model.Config{Root: root, Mounts: mountsFlag, Layers: layersFlag, Port: uint16(*portFlag),
+		Cert: *certFlag, Key: *keyFlag, Latency: latency, RateLimitKey: *rateLimitKeyFlag,
+		RequestBodySize: *bodySizeFlag, EchoHeaders: *echoHeadersFlag,
+		Upstreams: upstreamsFlag, UpstreamTimeout: *upstreamTimeoutFlag, Record: *recordFlag,
+		Watch: *watchFlag, WatchPoll: *watchPollFlag, Diagnostics: *diagnosticsFlag, Strict: *strictFlag,
+		Spec: *specFlag, SpecMode: *specModeFlag, JournalSize: *journalSizeFlag,
+		MgmtPrefix: *mgmtPrefixFlag, MgmtToken: *mgmtTokenFlag, MgmtAuth: *mgmtAuthFlag,
+		MgmtPort: uint16(*mgmtPortFlag), MgmtLoopback: *mgmtLoopbackFlag}
+	if file != nil {
+		config.ConfigFile, config.Directories = file.Path, file.Directories
+	}
+	config.Sources = sources
+	return config, nil
+}
+
+// mountsValue is a repeatable flag value for mounted root directories.
+type mountsValue []model.Mount
+
+// String returns mounted root directories as a comma-separated list.
+func (v *mountsValue) String() string {
+	values := make([]string, 0, len(*v))
+	for _, mount := range *v {
+		values = append(values, mount.Prefix+"="+mount.Root)
+	}
+	return strings.Join(values, ",")
+}
+
+// Set parses and adds mounted root directories from a comma-separated list.
+func (v *mountsValue) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		mount, err := model.ParseMount(strings.TrimSpace(item))
+		if err != nil {
+			return err
+		}
+		*v = append(*v, mount)
+	}
+	return nil
+}
+
+// layersValue is a repeatable flag value for layer directories.
+type layersValue []string
+
+// String returns layer directories as a comma-separated list.
+func (v *layersValue) String() string {
+	return strings.Join(*v, ",")
+}
+
+// Set adds layer directories from a comma-separated list.
+func (v *layersValue) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			*v = append(*v, item)
+		}
+	}
+	return nil
+}
+
+// upstreamsValue is a repeatable flag value for upstream servers.
+type upstreamsValue []model.Upstream
+
+// String returns upstream servers as a comma-separated list.
+func (v *upstreamsValue) String() string {
+	values := make([]string, 0, len(*v))
+	for _, upstream := range *v {
+		values = append(values, upstream.Prefix+"="+upstream.URL)
+	}
+	return strings.Join(values, ",")
+}
+
+// Set parses and adds upstream servers from a comma-separated list.
+func (v *upstreamsValue) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		upstream, err := model.ParseUpstream(strings.TrimSpace(item))
+		if err != nil {
+			return err
+		}
+		*v = append(*v, upstream)
+	}
+	return nil
+}
+
+// SetFlagsFromEnv sets flags of the flag set that are not set on the
+// command-line from the given environment variables (indexed by flag name).
+func SetFlagsFromEnv(fs *flag.FlagSet, envVars map[string]string) error {
+	set := setFlags(fs)
+	for name, envVar := range envVars {
+		if value := os.Getenv(envVar); !set[name] && len(value) > 0 {
+			if err := fs.Set(name, value); err != nil {
+				return errors.New("invalid " + envVar + " value")
+			}
+		}
+	}
+	return nil
 }
 
 // ValidateRootDirPath checks that the given server root path points to a valid directory.
@@ -100,6 +313,21 @@ func ValidateRootDirPath(root string) error {
 	return nil
 }
 
+// mgmtPrefixPattern is the pattern to validate the management endpoints prefix.
+var mgmtPrefixPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// ValidateManagementConfig checks that the management endpoints configuration is valid.
+func ValidateManagementConfig(prefix string, auth string, port uint, serverPort uint) error {
+	if !mgmtPrefixPattern.MatchString(prefix) {
+		return errors.New("invalid management prefix")
+	} else if user, _, found := strings.Cut(auth, ":"); len(auth) > 0 && (!found || len(user) == 0) {
+		return errors.New("management basic auth must use the user:password syntax")
+	} else if port != 0 && (port < 80 || port > math.MaxUint16 || port == serverPort) {
+		return errors.New("invalid management port number")
+	}
+	return nil
+}
+
 // ValidateTLSConfig checks that the TLS configuration is valid.
 func ValidateTLSConfig(cert, key string) error {
 	if len(cert) == 0 && len(key) == 0 {
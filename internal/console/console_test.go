@@ -4,6 +4,7 @@ import (
 	"flag"
 	"gaelgirodon.fr/liege/internal/model"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -16,6 +17,7 @@ func Test_Parse(t *testing.T) {
 		cert    string
 		key     string
 		latency string
+		rlKey   string
 	}
 	tests := []struct {
 		name    string
@@ -25,14 +27,29 @@ func Test_Parse(t *testing.T) {
 		wantErr bool
 	}{
 		{name: "ok/cli-min", args: []string{"l", ".."}, env: env{},
-			want: model.Config{Root: "..", Port: 3000, Latency: model.Latency{Min: 0, Max: 0}}},
+			want: model.Config{Root: "..", Port: 3000, RequestBodySize: model.DefaultRequestBodySize, Latency: model.Latency{Min: 0, Max: 0}}},
 		{name: "ok/cli-all", args: []string{"l", "-p=3001", "-c=" + files[0], "-k=" + files[1], "-l=5", ".."}, env: env{},
-			want: model.Config{Root: "..", Port: 3001, Cert: files[0], Key: files[1], Latency: model.Latency{Min: 5, Max: 5}}},
+			want: model.Config{Root: "..", Port: 3001, RequestBodySize: model.DefaultRequestBodySize, Cert: files[0], Key: files[1], Latency: model.Latency{Min: 5, Max: 5}}},
 		{name: "ok/env-all", args: []string{"l"}, env: env{root: "..", port: "3001", cert: files[0], key: files[1], latency: "4"},
-			want: model.Config{Root: "..", Port: 3001, Cert: files[0], Key: files[1], Latency: model.Latency{Min: 4, Max: 4}}},
+			want: model.Config{Root: "..", Port: 3001, RequestBodySize: model.DefaultRequestBodySize, Cert: files[0], Key: files[1], Latency: model.Latency{Min: 4, Max: 4}}},
 		{name: "ok/cli-env", args: []string{"l", "-p=3002", "-c=" + files[0], "-k=" + files[1], "-l=5-6", "./"},
 			env:  env{root: "..", port: "3001", cert: files[1], key: files[0], latency: "4"},
-			want: model.Config{Root: "./", Port: 3002, Cert: files[0], Key: files[1], Latency: model.Latency{Min: 5, Max: 6}}},
+			want: model.Config{Root: "./", Port: 3002, RequestBodySize: model.DefaultRequestBodySize, Cert: files[0], Key: files[1], Latency: model.Latency{Min: 5, Max: 6}}},
+		{name: "ok/rate-limit-key/cli", args: []string{"l", "-rate-limit-key=X-Client", "-k=" + files[1], "-c=" + files[0], ".."}, env: env{rlKey: "X-Env"},
+			want: model.Config{Root: "..", Port: 3000, RequestBodySize: model.DefaultRequestBodySize, Cert: files[0], Key: files[1], RateLimitKey: "X-Client"}},
+		{name: "ok/rate-limit-key/env", args: []string{"l", ".."}, env: env{rlKey: "X-Env"},
+			want: model.Config{Root: "..", Port: 3000, RequestBodySize: model.DefaultRequestBodySize, RateLimitKey: "X-Env"}},
+		{name: "ok/body-size/default", args: []string{"l", ".."}, env: env{},
+			want: model.Config{Root: "..", Port: 3000, RequestBodySize: model.DefaultRequestBodySize}},
+		{name: "ok/body-size-echo-headers", args: []string{"l", "-body-size=-1", "-echo-headers", ".."}, env: env{},
+			want: model.Config{Root: "..", Port: 3000, RequestBodySize: -1, EchoHeaders: true}},
+		{name: "ok/upstreams", args: []string{"l", "-u=http://localhost:8080", "-u=/api=http://localhost:8081,/v2=http://localhost:8082", ".."}, env: env{},
+			want: model.Config{Root: "..", Port: 3000, RequestBodySize: model.DefaultRequestBodySize, Upstreams: []model.Upstream{{Prefix: "/", URL: "http://localhost:8080"},
+				{Prefix: "/api", URL: "http://localhost:8081"}, {Prefix: "/v2", URL: "http://localhost:8082"}}}},
+		{name: "ok/mounts", args: []string{"l", "-m=/auth=..", "-m", "../../internal"}, env: env{},
+			want: model.Config{Port: 3000, RequestBodySize: model.DefaultRequestBodySize, Mounts: []model.Mount{{Prefix: "/auth", Root: ".."}, {Prefix: "/", Root: "../../internal"}}}},
+		{name: "err/mount-not-found", args: []string{"l", "-m=/auth=nowhere", ".."}, env: env{}, want: model.Config{}, wantErr: true},
+		{name: "err/record-mounts", args: []string{"l", "-m=..", "-record"}, env: env{}, want: model.Config{}, wantErr: true},
 		{name: "err/root-missing", args: []string{"l"}, env: env{}, want: model.Config{}, wantErr: true},
 		{name: "err/root-not-found", args: []string{"l", "nowhere"}, env: env{}, want: model.Config{}, wantErr: true},
 		{name: "err/root-not-dir", args: []string{"l", "cli.go"}, env: env{}, want: model.Config{}, wantErr: true},
@@ -41,6 +58,14 @@ func Test_Parse(t *testing.T) {
 		{name: "err/key-no-cert", args: []string{"l", "-k=" + files[1], ".."}, env: env{}, want: model.Config{}, wantErr: true},
 		{name: "err/bad-cert", args: []string{"l", "-c=bad", "-k=" + files[1], ".."}, env: env{}, want: model.Config{}, wantErr: true},
 		{name: "err/bad-key", args: []string{"l", "-c=" + files[0], "-k=bad", ".."}, env: env{}, want: model.Config{}, wantErr: true},
+		{name: "err/body-size", args: []string{"l", "-body-size=-2", ".."}, env: env{}, want: model.Config{}, wantErr: true},
+		{name: "err/upstream-timeout", args: []string{"l", "-upstream-timeout=0", ".."}, env: env{}, want: model.Config{}, wantErr: true},
+		{name: "err/spec", args: []string{"l", "-spec=missing.json", ".."}, env: env{}, want: model.Config{}, wantErr: true},
+		{name: "err/spec-mode", args: []string{"l", "-spec-mode=ignore", ".."}, env: env{}, want: model.Config{}, wantErr: true},
+		{name: "err/journal-size", args: []string{"l", "-journal-size=-2", ".."}, env: env{}, want: model.Config{}, wantErr: true},
+		{name: "err/mgmt-prefix", args: []string{"l", "-mgmt-prefix=_liege/", ".."}, env: env{}, want: model.Config{}, wantErr: true},
+		{name: "err/mgmt-auth", args: []string{"l", "-mgmt-auth=admin", ".."}, env: env{}, want: model.Config{}, wantErr: true},
+		{name: "err/mgmt-port", args: []string{"l", "-mgmt-port=3000", ".."}, env: env{}, want: model.Config{}, wantErr: true},
 		{name: "err/latency", args: []string{"l", "-l=999999", ".."}, env: env{}, want: model.Config{}, wantErr: true},
 	}
 	for _, test := range tests {
@@ -52,6 +77,7 @@ func Test_Parse(t *testing.T) {
 			_ = os.Setenv(CertEnvVar, test.env.cert)
 			_ = os.Setenv(KeyEnvVar, test.env.key)
 			_ = os.Setenv(LatencyEnvVar, test.env.latency)
+			_ = os.Setenv(RateLimitKeyEnvVar, test.env.rlKey)
 			// Reset flags configuration
 			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 			// Run
@@ -78,6 +104,21 @@ func Test_Parse(t *testing.T) {
 			if args.Latency != test.want.Latency {
 				t.Errorf("want latency = %v, got %v", test.want.Latency, args.Latency)
 			}
+			if args.RequestBodySize != test.want.RequestBodySize {
+				t.Errorf("want request body size = %v, got %v", test.want.RequestBodySize, args.RequestBodySize)
+			}
+			if args.EchoHeaders != test.want.EchoHeaders {
+				t.Errorf("want echo headers = %v, got %v", test.want.EchoHeaders, args.EchoHeaders)
+			}
+			if !reflect.DeepEqual(args.Mounts, test.want.Mounts) {
+				t.Errorf("want mounts = %v, got %v", test.want.Mounts, args.Mounts)
+			}
+			if !reflect.DeepEqual(args.Upstreams, test.want.Upstreams) {
+				t.Errorf("want upstreams = %v, got %v", test.want.Upstreams, args.Upstreams)
+			}
+			if args.RateLimitKey != test.want.RateLimitKey {
+				t.Errorf("want rate limit key = %v, got %v", test.want.RateLimitKey, args.RateLimitKey)
+			}
 		})
 	}
 }
@@ -103,3 +144,62 @@ func Test_ValidateRootDirPath(t *testing.T) {
 		})
 	}
 }
+
+func Test_ParseConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := dir + "/" + ConfigFileName
+	_ = os.WriteFile(configPath, []byte(`{"root":".","port":3001,"latency":{"min":5,"max":10},"record":true,
+		"upstreams":[{"prefix":"/api","url":"http://localhost:8080"}],"directories":{"/items/":{"latency":"100"}}}`), 0644)
+	_ = os.WriteFile(dir+"/liege.yaml", []byte("port: 3001"), 0644)
+	_ = os.WriteFile(dir+"/unknown.json", []byte(`{"unknown":true}`), 0644)
+	_ = os.WriteFile(dir+"/invalid.json", []byte(`{"port":"none"}`), 0644)
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		wantPort    uint16
+		wantLatency model.Latency
+		wantSources map[string]string
+		wantErr     string
+	}{
+		{name: "ok/root-dir", args: []string{"l", dir}, wantPort: 3001, wantLatency: model.Latency{Min: 5, Max: 10},
+			wantSources: map[string]string{"root": "flag", "port": "file", "latency": "file", "body_size": "default"}},
+		{name: "ok/flag", args: []string{"l", "-f", configPath, "-p", "3002"}, wantPort: 3002, wantLatency: model.Latency{Min: 5, Max: 10},
+			wantSources: map[string]string{"root": "file", "port": "flag", "latency": "file"}},
+		{name: "ok/env", args: []string{"l", "-l", "1"}, env: map[string]string{ConfigFileEnvVar: configPath, PortEnvVar: "3003"},
+			wantPort: 3003, wantLatency: model.Latency{Min: 1, Max: 1},
+			wantSources: map[string]string{"root": "file", "port": "env", "latency": "flag"}},
+		{name: "err/yaml", args: []string{"l", "-f", dir + "/liege.yaml"}, wantErr: "only JSON"},
+		{name: "err/unknown-key", args: []string{"l", "-f", dir + "/unknown.json", dir}, wantErr: "unknown configuration key"},
+		{name: "err/invalid-value", args: []string{"l", "-f", dir + "/invalid.json", dir}, wantErr: "invalid port value"},
+		{name: "err/missing", args: []string{"l", "-f", dir + "/missing.json", dir}, wantErr: "unable to read"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.Args = test.args
+			for _, envVar := range []string{RootEnvVar, PortEnvVar, LatencyEnvVar, ConfigFileEnvVar} {
+				_ = os.Setenv(envVar, test.env[envVar])
+			}
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+			config, err := ParseArgs()
+			if len(test.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("want error '%s', got '%v'", test.wantErr, err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("want no error, got '%v'", err)
+			}
+			if config.Port != test.wantPort || config.Latency != test.wantLatency || !config.Record ||
+				len(config.Upstreams) != 1 || config.Directories["items"].Latency.Min != 100 {
+				t.Errorf("want port = %d and latency = %v from the file, got %+v", test.wantPort, test.wantLatency, config)
+			}
+			for key, source := range test.wantSources {
+				if config.Sources[key] != source {
+					t.Errorf("want %s source = %s, got %s", key, source, config.Sources[key])
+				}
+			}
+		})
+	}
+	_ = os.Unsetenv(ConfigFileEnvVar)
+}
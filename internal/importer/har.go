@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"gaelgirodon.fr/liege/internal/model"
+	"gaelgirodon.fr/liege/internal/server"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// har is an HTTP Archive (only the fields used to import stub files).
+type har struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+// harEntry is an HTTP Archive entry (a request and its response).
+type harEntry struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		Status  int          `json:"status"`
+		Headers []harNameVal `json:"headers"`
+		Content struct {
+			Size     int    `json:"size"`
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// harNameVal is an HTTP Archive name/value pair.
+type harNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HAR imports entries from an HTTP Archive file as stub files into the root directory.
+func HAR(path string, root string) (Report, error) {
+	var report Report
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return report, errors.New("unable to read HAR file")
+	}
+	var archive har
+	if err = json.Unmarshal(data, &archive); err != nil {
+		return report, errors.New("invalid HAR file: " + err.Error())
+	}
+	if err = os.MkdirAll(root, 0755); err != nil {
+		return report, errors.New("unable to create the root directory")
+	}
+	w := newWriter(root, &report)
+	for _, entry := range archive.Log.Entries {
+		desc := entry.Request.Method + " " + entry.Request.URL
+		route, err := entry.route()
+		if err != nil {
+			report.Skipped = append(report.Skipped, desc+": "+err.Error())
+			continue
+		}
+		w.write(desc, route)
+	}
+	return report, nil
+}
+
+// route builds the stub route from the archive entry.
+func (e harEntry) route() (model.Route, error) {
+	route := model.NewRoute()
+	target, err := url.Parse(e.Request.URL)
+	if err != nil || target.Scheme != "http" && target.Scheme != "https" {
+		return route, errors.New("unsupported URL")
+	} else if e.Response.Status < 100 || e.Response.Status > 599 {
+		return route, errors.New("no response")
+	}
+	route.Path = target.Path
+	route.Method = e.Request.Method
+	route.Code = e.Response.Status
+	route.QueryParams = queryParams(target.Query())
+	route.ContentType = e.Response.Content.MimeType
+	content := e.Response.Content
+	if content.Encoding == "base64" {
+		if route.Content, err = base64.StdEncoding.DecodeString(content.Text); err != nil {
+			return route, errors.New("invalid base64 content")
+		}
+	} else {
+		route.Content = []byte(content.Text)
+	}
+	if len(route.Content) == 0 && content.Size > 0 {
+		return route, errors.New("response content not captured")
+	}
+	header := http.Header{}
+	for _, h := range e.Response.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	route.Headers = server.RecordableHeaders(header)
+	return route, nil
+}
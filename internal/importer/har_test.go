@@ -0,0 +1,53 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHAR(t *testing.T) {
+	dir := t.TempDir()
+	harFile := filepath.Join(dir, "session.har")
+	_ = os.WriteFile(harFile, []byte(`{"log":{"entries":[
+		{"request":{"method":"GET","url":"https://example.com/api/items?page=2"},
+		 "response":{"status":200,"headers":[{"name":"Content-Type","value":"application/json"},{"name":"ETag","value":"1"}],
+		  "content":{"size":2,"mimeType":"application/json","text":"[]"}}},
+		{"request":{"method":"GET","url":"https://example.com/api/items?page=2"},
+		 "response":{"status":200,"content":{"size":4,"mimeType":"application/json","text":"[{}]"}}},
+		{"request":{"method":"GET","url":"https://example.com/logo.png"},
+		 "response":{"status":200,"content":{"size":3,"mimeType":"image/png","text":"iVBO","encoding":"base64"}}},
+		{"request":{"method":"POST","url":"https://example.com/login"},
+		 "response":{"status":302,"headers":[{"name":"Location","value":"/home"}],"content":{"size":0}}},
+		{"request":{"method":"GET","url":"data:image/png;base64,iVBO"},"response":{"status":200,"content":{}}},
+		{"request":{"method":"GET","url":"https://example.com/big"},
+		 "response":{"status":200,"content":{"size":1000,"mimeType":"text/plain"}}},
+		{"request":{"method":"GET","url":"https://example.com/items__GET"},"response":{"status":200,"content":{}}},
+		{"request":{"method":"PROPFIND","url":"https://example.com/files"},"response":{"status":207,"content":{}}}
+	]}}`), 0666)
+	root := filepath.Join(dir, "data")
+	report, err := HAR(harFile, root)
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	wantWritten := []string{filepath.Join("api", "items__GET_qpage=2.json"), "logo__GET.png", "login__POST_302"}
+	if len(report.Written) != len(wantWritten) {
+		t.Fatalf("want written = %v, got %v", wantWritten, report.Written)
+	}
+	for i, relPath := range wantWritten {
+		if report.Written[i] != relPath {
+			t.Errorf("want written[%d] = %v, got %v", i, relPath, report.Written[i])
+		} else if _, err := os.Stat(filepath.Join(root, relPath)); err != nil {
+			t.Errorf("want %v to exist, got %v", relPath, err)
+		}
+	}
+	if len(report.Duplicates) != 1 || len(report.Skipped) != 4 {
+		t.Errorf("want 1 duplicate and 4 skipped entries, got %v and %v", report.Duplicates, report.Skipped)
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, "logo__GET.png")); len(content) != 3 {
+		t.Errorf("want base64 content to be decoded, got %v", content)
+	}
+	if meta, _ := os.ReadFile(filepath.Join(root, "login__POST_302.liege.json")); len(meta) == 0 {
+		t.Errorf("want Location header to be written in a sidecar file")
+	}
+}
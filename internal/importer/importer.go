@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"gaelgirodon.fr/liege/internal/model"
+	"gaelgirodon.fr/liege/internal/server"
+	"net/url"
+	"sort"
+)
+
+// Report is the result of an import.
+type Report struct {
+	// Written are the paths to the written stub files.
+	Written []string
+	// Skipped describes the items that could not be imported.
+	Skipped []string
+	// Duplicates describes the items not imported because a stub file
+	// has already been imported for the same route (the first one is kept).
+	Duplicates []string
+}
+
+// writer writes imported stub files into a root directory and reports the result.
+type writer struct {
+	// root is the path to the root directory.
+	root string
+	// report is the import report to complete.
+	report *Report
+	// written indexes the paths to the stub files written during the import.
+	written map[string]bool
+}
+
+// newWriter creates a writer for stub files imported into the given root directory.
+func newWriter(root string, report *Report) *writer {
+	return &writer{root: root, report: report, written: make(map[string]bool)}
+}
+
+// write writes the stub file for the given route unless a stub file has already been
+// written for the same route during the import (the first one is kept).
+func (w *writer) write(desc string, route model.Route) {
+	relPath, err := server.StubPath(w.root, route)
+	if err != nil {
+		w.report.Skipped = append(w.report.Skipped, desc+": "+err.Error())
+		return
+	} else if w.written[relPath] {
+		w.report.Duplicates = append(w.report.Duplicates, desc+" (already imported to "+relPath+")")
+		return
+	}
+	if _, _, err = server.WriteStub(w.root, route); err != nil {
+		w.report.Skipped = append(w.report.Skipped, desc+": "+err.Error())
+		return
+	}
+	w.written[relPath] = true
+	w.report.Written = append(w.report.Written, relPath)
+}
+
+// queryParams converts query parameters to required route query parameters
+// (using the first value of each parameter), sorted by name.
+func queryParams(query url.Values) []model.QueryParam {
+	params := make([]model.QueryParam, 0, len(query))
+	for name, values := range query {
+		params = append(params, model.QueryParam{Name: name, Value: values[0]})
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params
+}
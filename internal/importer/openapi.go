@@ -0,0 +1,235 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gaelgirodon.fr/liege/internal/model"
+	"gaelgirodon.fr/liege/internal/openapi"
+	"mime"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// StatusQueryParam is the query parameter selecting a response
+	// other than the default one for an operation.
+	StatusQueryParam = "liege-status"
+	// ExampleQueryParam is the query parameter selecting an example
+	// other than the first one for a response.
+	ExampleQueryParam = "liege-example"
+	// FirstExample is the examples naming strategy that imports the first example only.
+	FirstExample = "first"
+	// QueryExample is the examples naming strategy that imports all examples, the
+	// first one is served by default and others are selected with ExampleQueryParam.
+	QueryExample = "query"
+)
+
+var (
+	// pathParamPattern is the pattern to match a parameter in a path template.
+	pathParamPattern = regexp.MustCompile(`\{([^}]+)}`)
+	// invalidNameChars is the pattern to match characters not allowed in an option value.
+	invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// OpenAPIOptions are the options to import stub files from an OpenAPI document.
+type OpenAPIOptions struct {
+	// DefaultCode is the status code of the response served by default for each
+	// operation (if empty or missing, the lowest success status code is used).
+	DefaultCode string
+	// Examples is the naming strategy for responses with several examples
+	// (FirstExample or QueryExample).
+	Examples string
+}
+
+// example is a named response example.
+type example struct {
+	// name is the example name.
+	name string
+	// content is the serialized example value.
+	content []byte
+}
+
+// OpenAPI imports response examples from an OpenAPI document as stub files into
+// the root directory: one file per operation, response status code and example.
+func OpenAPI(path string, root string, opts OpenAPIOptions) (Report, error) {
+	var report Report
+	if opts.Examples != FirstExample && opts.Examples != QueryExample {
+		return report, errors.New("invalid examples naming strategy '" + opts.Examples + "'")
+	}
+	doc, err := openapi.Load(path)
+	if err != nil {
+		return report, err
+	}
+	if err = os.MkdirAll(root, 0755); err != nil {
+		return report, errors.New("unable to create the root directory")
+	}
+	w := newWriter(root, &report)
+	for _, template := range sortedKeys(doc.Paths) {
+		item := doc.Paths[template]
+		ops := item.Operations()
+		for _, method := range sortedKeys(ops) {
+			op := ops[method]
+			urlPath := pathFromTemplate(template, append(append([]*openapi.Parameter{}, item.Parameters...), op.Parameters...))
+			defaultCode := selectDefaultCode(op.Responses, opts.DefaultCode)
+			for _, code := range sortedKeys(op.Responses) {
+				desc := method + " " + template + " " + code
+				status, err := strconv.Atoi(code)
+				if err != nil || status < 100 || status > 599 {
+					report.Skipped = append(report.Skipped, desc+": unsupported status code")
+					continue
+				}
+				res := op.Responses[code]
+				contentType, examples := responseExamples(res)
+				if opts.Examples == FirstExample {
+					examples = examples[:1]
+				}
+				for i, ex := range examples {
+					route := model.NewRoute()
+					route.Path = urlPath
+					route.Method = method
+					route.Code = status
+					route.ContentType = contentType
+					route.Content = ex.content
+					route.Headers = responseHeaders(res)
+					if code != defaultCode {
+						route.QueryParams = append(route.QueryParams, model.QueryParam{Name: StatusQueryParam, Value: code})
+					}
+					exDesc := desc
+					if i > 0 {
+						route.QueryParams = append(route.QueryParams, model.QueryParam{Name: ExampleQueryParam, Value: ex.name})
+						exDesc += " (" + ex.name + ")"
+					}
+					w.write(exDesc, route)
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// pathFromTemplate builds an URL path from a path template by replacing
+// parameters with example values (or the parameter name).
+func pathFromTemplate(template string, params []*openapi.Parameter) string {
+	return pathParamPattern.ReplaceAllStringFunc(template, func(match string) string {
+		name := match[1 : len(match)-1]
+		value := any(name)
+		for _, p := range params {
+			if p != nil && p.In == "path" && p.Name == name {
+				if p.Example != nil {
+					value = p.Example
+				} else if p.Schema != nil && (p.Schema.Example != nil || p.Schema.Default != nil || len(p.Schema.Enum) > 0) {
+					value = openapi.Sample(p.Schema)
+				}
+			}
+		}
+		return fmt.Sprint(value)
+	})
+}
+
+// selectDefaultCode returns the status code of the response to serve by default:
+// the wanted code if the operation defines it, the lowest success code otherwise.
+func selectDefaultCode(responses map[string]*openapi.Response, wanted string) string {
+	if _, found := responses[wanted]; found && len(wanted) > 0 {
+		return wanted
+	}
+	codes := sortedKeys(responses)
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			return code
+		}
+	}
+	if len(codes) > 0 {
+		return codes[0]
+	}
+	return ""
+}
+
+// responseExamples returns the response content type (preferring JSON) and
+// examples (or a sample built from the schema), at least one (maybe empty).
+func responseExamples(res *openapi.Response) (string, []example) {
+	if res == nil || len(res.Content) == 0 {
+		return "", []example{{name: "empty"}}
+	}
+	types := sortedKeys(res.Content)
+	contentType := types[0]
+	for _, t := range types {
+		if isJSON(t) {
+			contentType = t
+			break
+		}
+	}
+	mt := res.Content[contentType]
+	var examples []example
+	if mt.Example != nil {
+		examples = append(examples, example{name: "example", content: serialize(mt.Example, contentType)})
+	}
+	for _, name := range sortedKeys(mt.Examples) {
+		if ex := mt.Examples[name]; ex != nil {
+			examples = append(examples, example{name: optionValue(name, len(examples)),
+				content: serialize(ex.Value, contentType)})
+		}
+	}
+	if len(examples) == 0 {
+		examples = append(examples, example{name: "sample", content: serialize(openapi.Sample(mt.Schema), contentType)})
+	}
+	return contentType, examples
+}
+
+// responseHeaders returns response headers with an example value.
+func responseHeaders(res *openapi.Response) map[string]string {
+	var headers map[string]string
+	for name, h := range res.Headers {
+		value := h.Example
+		if value == nil && h.Schema != nil {
+			value = h.Schema.Example
+		}
+		if value != nil {
+			if headers == nil {
+				headers = make(map[string]string)
+			}
+			headers[name] = fmt.Sprint(value)
+		}
+	}
+	return headers
+}
+
+// serialize serializes an example value for the given content type.
+func serialize(value any, contentType string) []byte {
+	if value == nil {
+		return nil
+	} else if str, ok := value.(string); ok && !isJSON(contentType) {
+		return []byte(str)
+	}
+	data, _ := json.MarshalIndent(value, "", "  ")
+	return data
+}
+
+// isJSON indicates whether the content type is a JSON media type.
+func isJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// optionValue converts a name into a valid file name option value
+// (or the given index if nothing remains).
+func optionValue(name string, index int) string {
+	value := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(value) == 0 {
+		return strconv.Itoa(index + 1)
+	}
+	return value
+}
+
+// sortedKeys returns the keys of a map sorted in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        OpenAPIOptions
+		wantWritten []string
+		wantSkipped int
+		wantErr     bool
+	}{
+		{"first", OpenAPIOptions{Examples: FirstExample}, []string{
+			"pets__GET.json",
+			"pets__GET_qliege-status=500_500.json",
+			"pets__POST_201",
+			"pets__POST_qliege-status=400_400.json",
+			filepath.Join("pets", "1__GET.json"),
+		}, 1, false},
+		{"query", OpenAPIOptions{DefaultCode: "500", Examples: QueryExample}, []string{
+			"pets__GET_qliege-status=200.json",
+			"pets__GET_qliege-example=empty_qliege-status=200.json",
+			"pets__GET_500.json",
+			"pets__POST_201",
+			"pets__POST_qliege-status=400_400.json",
+			filepath.Join("pets", "1__GET.json"),
+		}, 1, false},
+		{"err/examples", OpenAPIOptions{Examples: "unknown"}, nil, 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			report, err := OpenAPI("../openapi/testdata/petstore.json", root, test.opts)
+			if test.wantErr != (err != nil) {
+				t.Fatalf("want error = %v, got %v (%v)", test.wantErr, err != nil, err)
+			}
+			if len(report.Written) != len(test.wantWritten) {
+				t.Fatalf("want written = %v, got %v", test.wantWritten, report.Written)
+			}
+			for i, relPath := range test.wantWritten {
+				if report.Written[i] != relPath {
+					t.Errorf("want written[%d] = %v, got %v", i, relPath, report.Written[i])
+				} else if _, err := os.Stat(filepath.Join(root, relPath)); err != nil {
+					t.Errorf("want %v to exist, got %v", relPath, err)
+				}
+			}
+			if len(report.Skipped) != test.wantSkipped {
+				t.Errorf("want %d skipped, got %v", test.wantSkipped, report.Skipped)
+			}
+		})
+	}
+}
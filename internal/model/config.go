@@ -1,11 +1,48 @@
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRequestBodySize is the default maximum size of a request body
+	// sent back in a response header.
+	DefaultRequestBodySize = 4096
+	// DefaultUpstreamTimeout is the default upstream request timeout in ms.
+	DefaultUpstreamTimeout = 30000
+	// DefaultManagementPrefix is the default URL path prefix of management endpoints.
+	DefaultManagementPrefix = "/_liege"
+	// DefaultJournalSize is the default maximum number of requests kept in the journal.
+	DefaultJournalSize = 1000
+	// RejectViolations is the validation mode rejecting requests violating
+	// the OpenAPI specification with a 400 response.
+	RejectViolations = "reject"
+	// ReportViolations is the validation mode serving requests violating the
+	// OpenAPI specification with violations reported in a header and logged.
+	ReportViolations = "report"
+	// SourceFlag is the source of a configuration value set on the command-line.
+	SourceFlag = "flag"
+	// SourceEnv is the source of a configuration value set from an environment variable.
+	SourceEnv = "env"
+	// SourceFile is the source of a configuration value set from the configuration file.
+	SourceFile = "file"
+	// SourceDefault is the source of a configuration value that has its default value.
+	SourceDefault = "default"
+	// SourceAPI is the source of a configuration value updated using the management API.
+	SourceAPI = "api"
+)
 
 // Config is the application configuration.
 type Config struct {
 	// Root is the path to the root server directory.
 	Root string `json:"root"`
+	// Mounts are additional root directories served under URL path prefixes.
+	Mounts []Mount `json:"mounts,omitempty"`
+	// Layers are directories overlaid on top of root directories (lowest first):
+	// their routes replace routes matching the same requests in lower layers.
+	Layers []string `json:"layers,omitempty"`
 	// Port is the HTTP server port number.
 	Port uint16 `json:"-"`
 	// Cert is the path to the TLS certificate PEM file.
@@ -14,6 +51,98 @@ type Config struct {
 	Key string `json:"-"`
 	// Latency is the simulated response latency value.
 	Latency Latency `json:"latency"`
+	// RateLimitKey is the name of the request header identifying clients
+	// for rate limiting (the client IP address is used by default).
+	RateLimitKey string `json:"-"`
+	// RequestBodySize is the maximum size of a request body sent back in a
+	// response header (0 for the default size, -1 to disable).
+	RequestBodySize int `json:"-"`
+	// EchoHeaders indicates whether request headers are sent back in response headers.
+	EchoHeaders bool `json:"-"`
+	// Upstreams are the servers to forward unmatched requests to.
+	Upstreams []Upstream `json:"upstreams,omitempty"`
+	// UpstreamTimeout is the upstream request timeout in ms (0 for the default timeout).
+	UpstreamTimeout int `json:"-"`
+	// Record indicates whether proxied exchanges are recorded as stub files.
+	Record bool `json:"record,omitempty"`
+	// Watch indicates whether routes are rebuilt when stub files change.
+	Watch bool `json:"watch,omitempty"`
+	// WatchPoll indicates whether stub files changes are detected by polling
+	// modification times instead of using inotify.
+	WatchPoll bool `json:"-"`
+	// Diagnostics indicates whether unmatched requests get a response
+	// listing the routes almost matching them.
+	Diagnostics bool `json:"diagnostics,omitempty"`
+	// Strict indicates whether loading routes fails when any stub file cannot be loaded.
+	Strict bool `json:"strict,omitempty"`
+	// Spec is the path to the OpenAPI document requests are validated against.
+	Spec string `json:"-"`
+	// SpecMode is the validation mode (RejectViolations or ReportViolations).
+	SpecMode string `json:"-"`
+	// JournalSize is the maximum number of requests kept in the journal
+	// (0 for the default size, -1 to disable).
+	JournalSize int `json:"-"`
+	// MgmtPrefix is the URL path prefix of management endpoints (empty for the default prefix).
+	MgmtPrefix string `json:"-"`
+	// MgmtToken is the static bearer token required to call management endpoints (if set).
+	MgmtToken string `json:"-"`
+	// MgmtAuth is the basic auth credentials (user:password) required to call
+	// management endpoints (if set).
+	MgmtAuth string `json:"-"`
+	// MgmtPort is the port number of a separate listener for management
+	// endpoints (0 to serve them with stubs).
+	MgmtPort uint16 `json:"-"`
+	// MgmtLoopback indicates whether management endpoints are restricted to the loopback interface.
+	MgmtLoopback bool `json:"-"`
+	// Directories are the default options of stub files per directory.
+	Directories Directories `json:"directories,omitempty"`
+	// ConfigFile is the path to the loaded configuration file (if any).
+	ConfigFile string `json:"config_file,omitempty"`
+	// Sources are the sources of configuration values indexed by configuration key
+	// (SourceFlag, SourceEnv, SourceFile, SourceDefault or SourceAPI).
+	Sources map[string]string `json:"sources,omitempty"`
+}
+
+// DirectoryDefaults are the default options of stub files in a directory
+// (and its subdirectories), applied when not set in file names.
+type DirectoryDefaults struct {
+	// Latency is the default simulated response latency (-1 if undefined).
+	Latency Latency `json:"latency"`
+	// RateLimit is the default rate limit.
+	RateLimit RateLimit `json:"rate_limit,omitzero"`
+	// Headers are default response headers.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Directories maps directory paths (relative to the root directory,
+// slash-separated, empty for the root directory) to stub file default options.
+type Directories map[string]DirectoryDefaults
+
+// Defaults returns the default options of stub files in the given directory
+// (relative to the root directory, slash-separated), merging the defaults of
+// its parent directories (the closest directory wins).
+func (dirs Directories) Defaults(dir string) DirectoryDefaults {
+	defaults := DirectoryDefaults{Latency: Latency{Min: -1, Max: -1}}
+	parts := strings.Split(dir, "/")
+	for i := 0; i <= len(parts); i++ {
+		d, exists := dirs[strings.Join(parts[:i], "/")]
+		if !exists || i > 0 && len(parts[0]) == 0 {
+			continue
+		}
+		if !d.Latency.IsDisabledOrUndefined() {
+			defaults.Latency = d.Latency
+		}
+		if d.RateLimit.IsEnabled() {
+			defaults.RateLimit = d.RateLimit
+		}
+		for name, value := range d.Headers {
+			if defaults.Headers == nil {
+				defaults.Headers = make(map[string]string)
+			}
+			defaults.Headers[name] = value
+		}
+	}
+	return defaults
 }
 
 // Address returns the HTTP server address.
@@ -21,7 +150,77 @@ func (c *Config) Address() string {
 	return ":" + fmt.Sprint(c.Port)
 }
 
+// RootDirs returns all directories stub files are loaded from:
+// the root directory (if any), mounted root directories and layers.
+func (c *Config) RootDirs() []string {
+	var dirs []string
+	if len(c.Root) > 0 {
+		dirs = append(dirs, c.Root)
+	}
+	for _, mount := range c.Mounts {
+		dirs = append(dirs, mount.Root)
+	}
+	return append(dirs, c.Layers...)
+}
+
+// ManagementPrefix returns the URL path prefix of management endpoints.
+func (c *Config) ManagementPrefix() string {
+	if len(c.MgmtPrefix) == 0 {
+		return DefaultManagementPrefix
+	}
+	return c.MgmtPrefix
+}
+
+// ManagementAddress returns the address of the separate management listener.
+func (c *Config) ManagementAddress() string {
+	if c.MgmtLoopback {
+		return "127.0.0.1:" + fmt.Sprint(c.MgmtPort)
+	}
+	return ":" + fmt.Sprint(c.MgmtPort)
+}
+
+// HasManagementAuth indicates whether management endpoints require authentication.
+func (c *Config) HasManagementAuth() bool {
+	return len(c.MgmtToken) > 0 || len(c.MgmtAuth) > 0
+}
+
 // HasTLS indicates whether TLS configuration is provided or not.
 func (c *Config) HasTLS() bool {
 	return len(c.Cert) > 0 && len(c.Key) > 0
 }
+
+// MaxRequestBodySize returns the maximum size of a request body
+// sent back in a response header.
+func (c *Config) MaxRequestBodySize() int {
+	if c.RequestBodySize == 0 {
+		return DefaultRequestBodySize
+	}
+	return c.RequestBodySize
+}
+
+// MaxJournalSize returns the maximum number of requests kept in the journal.
+func (c *Config) MaxJournalSize() int {
+	if c.JournalSize == 0 {
+		return DefaultJournalSize
+	}
+	return c.JournalSize
+}
+
+// Upstream returns the upstream server to forward a request on the given
+// URL path to (the one with the longest matching prefix), if any.
+func (c *Config) Upstream(path string) (upstream Upstream, found bool) {
+	for _, u := range c.Upstreams {
+		if u.Matches(path) && (!found || len(u.Prefix) > len(upstream.Prefix)) {
+			upstream, found = u, true
+		}
+	}
+	return
+}
+
+// UpstreamTimeoutDuration returns the upstream request timeout.
+func (c *Config) UpstreamTimeoutDuration() time.Duration {
+	if c.UpstreamTimeout == 0 {
+		return DefaultUpstreamTimeout * time.Millisecond
+	}
+	return time.Duration(c.UpstreamTimeout) * time.Millisecond
+}
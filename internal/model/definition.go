@@ -0,0 +1,93 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// idPattern is the pattern to validate a runtime route identifier.
+	idPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+	// methodPattern is the pattern to validate an HTTP method.
+	methodPattern = regexp.MustCompile(`^[A-Z]+$`)
+)
+
+// RouteDefinition is the definition of a route created at runtime.
+type RouteDefinition struct {
+	// ID is the route identifier (generated if empty).
+	ID string `json:"id"`
+	// Path is the URL path on which to serve the response.
+	Path string `json:"path"`
+	// Method is the required HTTP method (any method if empty).
+	Method string `json:"method"`
+	// QueryParams are the required query parameters.
+	QueryParams []QueryParam `json:"query_params"`
+	// Code is the response status code (200 if empty).
+	Code int `json:"code"`
+	// ContentType is the response content type (guessed from the body if empty).
+	ContentType string `json:"content_type"`
+	// Headers are additional response headers.
+	Headers map[string]string `json:"headers"`
+	// Body is the response body.
+	Body string `json:"body"`
+	// Latency is the simulated response latency (global latency if nil).
+	Latency *Latency `json:"latency"`
+	// TTL is the route time-to-live in seconds (no expiration if 0).
+	TTL int `json:"ttl"`
+}
+
+// Validate checks that the route definition is valid.
+func (d *RouteDefinition) Validate() error {
+	if len(d.ID) > 0 && !idPattern.MatchString(d.ID) {
+		return errors.New("invalid route id")
+	} else if !strings.HasPrefix(d.Path, "/") || len(d.Path) > 1 && strings.HasSuffix(d.Path, "/") {
+		return errors.New("invalid route path")
+	} else if len(d.Method) > 0 && !methodPattern.MatchString(strings.ToUpper(d.Method)) {
+		return errors.New("invalid route method")
+	} else if d.Code != 0 && (d.Code < 100 || d.Code > 599) {
+		return errors.New("invalid route status code")
+	} else if d.Latency != nil && !d.Latency.IsValid() {
+		return errors.New("invalid latency value")
+	} else if d.TTL < 0 {
+		return errors.New("invalid route time-to-live")
+	}
+	for _, qp := range d.QueryParams {
+		if len(qp.Name) == 0 {
+			return errors.New("invalid route query parameter")
+		}
+	}
+	return nil
+}
+
+// Route builds the route from the definition, expiring after the TTL from the given time.
+func (d *RouteDefinition) Route(now time.Time) *Route {
+	route := NewRoute()
+	route.ID = d.ID
+	route.Path = d.Path
+	route.Method = strings.ToUpper(d.Method)
+	route.QueryParams = append(route.QueryParams, d.QueryParams...)
+	if d.Code != 0 {
+		route.Code = d.Code
+	}
+	route.Content = []byte(d.Body)
+	route.ContentType = d.ContentType
+	if len(route.ContentType) == 0 && len(route.Content) > 0 {
+		if json.Valid(route.Content) {
+			route.ContentType = "application/json"
+		} else {
+			route.ContentType = http.DetectContentType(route.Content)
+		}
+	}
+	route.Headers = d.Headers
+	if d.Latency != nil {
+		route.Latency = *d.Latency
+	}
+	if d.TTL > 0 {
+		route.Expires = now.Add(time.Duration(d.TTL) * time.Second)
+	}
+	return &route
+}
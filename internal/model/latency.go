@@ -51,14 +51,19 @@ func (l Latency) IsDisabledOrUndefined() bool {
 	return l.Min == -1
 }
 
-// Compute computes the duration to wait before sending the response.
-func (l Latency) Compute(global Latency) time.Duration {
-	lat := global // Take the global value by default
+// Resolve returns the latency range to apply given the global latency.
+func (l Latency) Resolve(global Latency) Latency {
 	if global.IsDisabledOrUndefined() {
-		lat = Latency{Min: 0, Max: 0} // Latency disabled globally
+		return Latency{Min: 0, Max: 0} // Latency disabled globally
 	} else if !l.IsDisabledOrUndefined() {
-		lat = l // Latency from file name overrides global latency
+		return l // Latency from file name overrides global latency
 	}
+	return global // Take the global value by default
+}
+
+// Compute computes the duration to wait before sending the response.
+func (l Latency) Compute(global Latency) time.Duration {
+	lat := l.Resolve(global)
 	var duration int
 	if lat.Min == lat.Max {
 		duration = lat.Min // Fixed value
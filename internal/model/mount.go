@@ -0,0 +1,35 @@
+package model
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+// Mount is a root directory served under a URL path prefix.
+type Mount struct {
+	// Prefix is the URL path prefix of the routes ("/" for no prefix).
+	Prefix string `json:"prefix"`
+	// Root is the path to the root directory.
+	Root string `json:"root"`
+}
+
+// ParseMount validates, parses and returns a mount value ([<prefix>=]<dir>).
+func ParseMount(value string) (Mount, error) {
+	mount := Mount{Prefix: "/", Root: value}
+	if prefix, root, found := strings.Cut(value, "="); found && strings.HasPrefix(prefix, "/") {
+		mount = Mount{Prefix: path.Clean(prefix), Root: root}
+	}
+	if len(mount.Root) == 0 {
+		return Mount{}, errors.New("invalid mount value")
+	}
+	return mount, nil
+}
+
+// Path returns the URL path of a route of the mounted directory.
+func (m Mount) Path(routePath string) string {
+	if m.Prefix == "/" || len(m.Prefix) == 0 {
+		return routePath
+	}
+	return strings.TrimSuffix(m.Prefix+routePath, "/")
+}
@@ -0,0 +1,44 @@
+package model
+
+import "testing"
+
+func TestParseMount(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Mount
+		wantErr bool
+	}{
+		{"./stubs", Mount{"/", "./stubs"}, false},
+		{"/=./stubs", Mount{"/", "./stubs"}, false},
+		{"/auth/=shared/auth", Mount{"/auth", "shared/auth"}, false},
+		{"a=b", Mount{"/", "a=b"}, false},
+		{"/auth=", Mount{}, true},
+		{"", Mount{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := ParseMount(test.value)
+			if (err != nil) != test.wantErr || got != test.want {
+				t.Errorf("want %+v (error = %v), got %+v (%v)", test.want, test.wantErr, got, err)
+			}
+		})
+	}
+}
+
+func TestMount_Path(t *testing.T) {
+	tests := []struct {
+		prefix string
+		path   string
+		want   string
+	}{
+		{"/", "/items", "/items"},
+		{"/", "/", "/"},
+		{"/auth", "/login", "/auth/login"},
+		{"/auth", "/", "/auth"},
+	}
+	for _, test := range tests {
+		if got := (Mount{Prefix: test.prefix}).Path(test.path); got != test.want {
+			t.Errorf("want %s, got %s", test.want, got)
+		}
+	}
+}
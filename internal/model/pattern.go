@@ -0,0 +1,156 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RequestPattern describes requests to look for in the journal.
+type RequestPattern struct {
+	// Method is the required HTTP method (any method if empty).
+	Method string `json:"method"`
+	// Path is the required URL path (any path if empty).
+	Path string `json:"path"`
+	// QueryParams are the required query parameters.
+	QueryParams []QueryParam `json:"query_params"`
+	// Headers are the required request headers
+	// (an empty value means that any value will match).
+	Headers map[string]string `json:"headers"`
+	// Body is the request body matcher (any body if nil).
+	Body *BodyMatcher `json:"body"`
+}
+
+// Mismatches checks the pattern against a request using the route matching rules
+// (and headers and body), and returns the reasons why the request doesn't match
+// (empty if it matches).
+func (p *RequestPattern) Mismatches(req Request) []string {
+	route := Route{Path: p.Path, Method: strings.ToUpper(p.Method), QueryParams: p.QueryParams}
+	if len(route.Path) == 0 {
+		route.Path = req.Path
+	}
+	reasons := route.Mismatches(req.Method, req.Path, req.Query)
+	for _, name := range sortedHeaderNames(p.Headers) {
+		values := req.Headers.Values(name)
+		if len(values) == 0 {
+			reasons = append(reasons, "header "+name+" is missing")
+		} else if value := p.Headers[name]; len(value) > 0 && !slices.Contains(values, value) {
+			reasons = append(reasons, "header "+name+" is not "+value)
+		}
+	}
+	if p.Body != nil {
+		if reason := p.Body.Mismatch(req.RawBody()); len(reason) > 0 {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// sortedHeaderNames returns the header names sorted in ascending order.
+func sortedHeaderNames(headers map[string]string) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// BodyMatcher describes a required request body, all set conditions must be met.
+type BodyMatcher struct {
+	// Equals is the exact expected body.
+	Equals *string `json:"equals"`
+	// Contains is a string the body must contain.
+	Contains string `json:"contains"`
+	// Matches is a regular expression the body must match.
+	Matches string `json:"matches"`
+	// JSON is a JSON value the body must be equal to (ignoring formatting).
+	JSON any `json:"json"`
+}
+
+// Validate checks that the body matcher is valid.
+func (m *BodyMatcher) Validate() error {
+	if _, err := regexp.Compile(m.Matches); err != nil {
+		return errors.New("invalid body regular expression")
+	}
+	return nil
+}
+
+// Mismatch checks the matcher against a request body and returns
+// the reason why the body doesn't match (empty if it matches).
+func (m *BodyMatcher) Mismatch(body []byte) string {
+	if m.Equals != nil && string(body) != *m.Equals {
+		return "body is not equal to the expected body"
+	} else if len(m.Contains) > 0 && !strings.Contains(string(body), m.Contains) {
+		return "body doesn't contain " + m.Contains
+	} else if len(m.Matches) > 0 && !regexp.MustCompile(m.Matches).Match(body) {
+		return "body doesn't match " + m.Matches
+	} else if m.JSON != nil {
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil || !reflect.DeepEqual(value, m.JSON) {
+			return "body is not equal to the expected JSON value"
+		}
+	}
+	return ""
+}
+
+// Verification is a request pattern with the expected number of matching requests.
+type Verification struct {
+	// Request is the pattern requests must match.
+	Request RequestPattern `json:"request"`
+	// Count is the exact expected number of matching requests.
+	Count *int `json:"count"`
+	// Min is the minimum expected number of matching requests.
+	Min *int `json:"min"`
+	// Max is the maximum expected number of matching requests.
+	Max *int `json:"max"`
+}
+
+// Validate checks that the verification is valid.
+func (v *Verification) Validate() error {
+	for _, n := range []*int{v.Count, v.Min, v.Max} {
+		if n != nil && *n < 0 {
+			return errors.New("invalid expected count")
+		}
+	}
+	if v.Count != nil && (v.Min != nil || v.Max != nil) {
+		return errors.New("count and range are mutually exclusive")
+	} else if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+		return errors.New("invalid expected count range")
+	}
+	if v.Request.Body != nil {
+		return v.Request.Body.Validate()
+	}
+	return nil
+}
+
+// Expects indicates whether the given number of matching requests is expected
+// (at least one request is expected if neither count nor range is set).
+func (v *Verification) Expects(n int) bool {
+	switch {
+	case v.Count != nil:
+		return n == *v.Count
+	case v.Min == nil && v.Max == nil:
+		return n >= 1
+	}
+	return (v.Min == nil || n >= *v.Min) && (v.Max == nil || n <= *v.Max)
+}
+
+// Expected returns a description of the expected number of matching requests.
+func (v *Verification) Expected() string {
+	switch {
+	case v.Count != nil:
+		return fmt.Sprintf("exactly %d", *v.Count)
+	case v.Min != nil && v.Max != nil:
+		return fmt.Sprintf("between %d and %d", *v.Min, *v.Max)
+	case v.Max != nil:
+		return fmt.Sprintf("at most %d", *v.Max)
+	case v.Min != nil:
+		return fmt.Sprintf("at least %d", *v.Min)
+	}
+	return "at least 1"
+}
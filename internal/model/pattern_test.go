@@ -0,0 +1,69 @@
+package model
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestRequestPattern_Mismatches(t *testing.T) {
+	text := `{"id":1}`
+	req := Request{Method: "POST", Path: "/pay", Query: url.Values{"a": {"1"}},
+		Headers: http.Header{"X-Id": {"42"}}, Body: `{"id": 1}`}
+	tests := []struct {
+		name    string
+		pattern RequestPattern
+		want    []string
+	}{
+		{"any", RequestPattern{}, nil},
+		{"match", RequestPattern{Method: "post", Path: "/pay", QueryParams: []QueryParam{{"a", "1"}},
+			Headers: map[string]string{"x-id": "42"}, Body: &BodyMatcher{Contains: `"id"`, Matches: `\d`,
+				JSON: map[string]any{"id": 1.0}}}, nil},
+		{"route", RequestPattern{Method: "PUT", Path: "/pay", QueryParams: []QueryParam{{"b", ""}}},
+			[]string{"method POST is not PUT", "query parameter b is missing"}},
+		{"headers", RequestPattern{Headers: map[string]string{"X-Id": "1", "X-Other": ""}},
+			[]string{"header X-Id is not 1", "header X-Other is missing"}},
+		{"body/equals", RequestPattern{Body: &BodyMatcher{Equals: &text}},
+			[]string{"body is not equal to the expected body"}},
+		{"body/json", RequestPattern{Body: &BodyMatcher{JSON: map[string]any{"id": 2.0}}},
+			[]string{"body is not equal to the expected JSON value"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.pattern.Mismatches(req); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestVerification_Expects(t *testing.T) {
+	one, two := 1, 2
+	tests := []struct {
+		name  string
+		v     Verification
+		count int
+		want  bool
+	}{
+		{"default/none", Verification{}, 0, false},
+		{"default/some", Verification{}, 3, true},
+		{"count", Verification{Count: &one}, 1, true},
+		{"count/more", Verification{Count: &one}, 2, false},
+		{"range", Verification{Min: &one, Max: &two}, 2, true},
+		{"range/max", Verification{Max: &one}, 2, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.v.Expects(test.count); got != test.want {
+				t.Errorf("want %v, got %v", test.want, got)
+			}
+		})
+	}
+	if err := (&Verification{Min: &two, Max: &one}).Validate(); err == nil {
+		t.Error("want error for an invalid range")
+	}
+	if err := (&Verification{Request: RequestPattern{Body: &BodyMatcher{Matches: "("}}}).Validate(); err == nil {
+		t.Error("want error for an invalid body regular expression")
+	}
+}
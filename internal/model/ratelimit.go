@@ -0,0 +1,48 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit is the maximum number of requests allowed during a period.
+type RateLimit struct {
+	// Limit is the maximum number of requests per period.
+	Limit int `json:"limit"`
+	// Period is the period duration in seconds.
+	Period int `json:"period"`
+}
+
+// rateLimitPattern is the pattern to validate and parse a rate limit value.
+var rateLimitPattern = regexp.MustCompile("^([0-9]{1,5})([smh])$")
+
+// rateLimitUnits maps rate limit period units to durations in seconds.
+var rateLimitUnits = map[string]int{"s": 1, "m": 60, "h": 3600}
+
+// ParseRateLimit validates, parses and returns a rate limit value
+// (e.g. 10s for 10 requests per second).
+func ParseRateLimit(value string, prefix string) (RateLimit, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return RateLimit{}, errors.New("invalid rate limit value")
+	}
+	if match := rateLimitPattern.FindStringSubmatch(value[len(prefix):]); len(match) == 3 {
+		limit, _ := strconv.Atoi(match[1])
+		if limit > 0 {
+			return RateLimit{Limit: limit, Period: rateLimitUnits[match[2]]}, nil
+		}
+	}
+	return RateLimit{}, errors.New("invalid rate limit value")
+}
+
+// IsEnabled indicates whether the rate limit is defined or not.
+func (rl RateLimit) IsEnabled() bool {
+	return rl.Limit > 0 && rl.Period > 0
+}
+
+// Duration returns the rate limit period duration.
+func (rl RateLimit) Duration() time.Duration {
+	return time.Duration(rl.Period) * time.Second
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    RateLimit
+		wantErr bool
+	}{
+		{"second", "rl10s", RateLimit{10, 1}, false},
+		{"minute", "rl5m", RateLimit{5, 60}, false},
+		{"hour", "rl1h", RateLimit{1, 3600}, false},
+		{"err/prefix", "l10s", RateLimit{}, true},
+		{"err/zero", "rl0s", RateLimit{}, true},
+		{"err/unit", "rl10d", RateLimit{}, true},
+		{"err/number", "rls", RateLimit{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rateLimit, err := ParseRateLimit(test.value, "rl")
+			if test.wantErr != (err != nil) {
+				t.Fatalf("want error = %v, got %v (%v)", test.wantErr, err != nil, err)
+			}
+			if rateLimit != test.want {
+				t.Errorf("want rate limit = %v, got %v", test.want, rateLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"unicode/utf8"
+)
+
+// Request is a captured HTTP request.
+type Request struct {
+	// Method is the request HTTP method.
+	Method string `json:"method"`
+	// URL is the request URL (path and query string).
+	URL string `json:"url"`
+	// Path is the request URL path.
+	Path string `json:"path"`
+	// Query contains the request query parameters.
+	Query url.Values `json:"query"`
+	// Headers contains the request headers.
+	Headers http.Header `json:"headers"`
+	// Body is the request body, base64 encoded if it is not valid UTF-8 text.
+	Body string `json:"body"`
+	// BodyEncoding is the request body encoding (empty or base64).
+	BodyEncoding string `json:"body_encoding,omitempty"`
+	// RemoteAddr is the network address of the client.
+	RemoteAddr string `json:"remote_addr"`
+	// TLS contains information about the TLS connection (if any).
+	TLS *TLSInfo `json:"tls,omitempty"`
+}
+
+// TLSInfo contains information about a TLS connection.
+type TLSInfo struct {
+	// Version is the TLS version.
+	Version string `json:"version"`
+	// CipherSuite is the cipher suite name.
+	CipherSuite string `json:"cipher_suite"`
+	// ServerName is the server name requested by the client (SNI).
+	ServerName string `json:"server_name"`
+	// NegotiatedProtocol is the protocol negotiated with ALPN.
+	NegotiatedProtocol string `json:"negotiated_protocol"`
+}
+
+// NewRequest captures the given HTTP request with its (already read) body.
+func NewRequest(req *http.Request, body []byte) Request {
+	r := Request{Method: req.Method, URL: req.URL.RequestURI(), Path: req.URL.Path,
+		Query: req.URL.Query(), Headers: req.Header.Clone(), RemoteAddr: req.RemoteAddr}
+	if utf8.Valid(body) {
+		r.Body = string(body)
+	} else {
+		r.Body = base64.StdEncoding.EncodeToString(body)
+		r.BodyEncoding = "base64"
+	}
+	if req.TLS != nil {
+		r.TLS = &TLSInfo{Version: tls.VersionName(req.TLS.Version),
+			CipherSuite:        tls.CipherSuiteName(req.TLS.CipherSuite),
+			ServerName:         req.TLS.ServerName,
+			NegotiatedProtocol: req.TLS.NegotiatedProtocol}
+	}
+	return r
+}
+
+// RawBody returns the request body (decoded if base64 encoded).
+func (r *Request) RawBody() []byte {
+	if r.BodyEncoding == "base64" {
+		body, _ := base64.StdEncoding.DecodeString(r.Body)
+		return body
+	}
+	return []byte(r.Body)
+}
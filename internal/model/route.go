@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 // Route is a stub route configuration.
@@ -22,8 +24,20 @@ type Route struct {
 	Content []byte `json:"-"`
 	// ContentType is the response content type.
 	ContentType string `json:"content_type"`
+	// Headers are additional response headers.
+	Headers map[string]string `json:"headers,omitempty"`
 	// Latency is the simulated response latency (ms).
 	Latency Latency `json:"latency"`
+	// RateLimit is the maximum number of requests allowed per client.
+	RateLimit RateLimit `json:"rate_limit,omitzero"`
+	// ID is the identifier of a route created at runtime (empty for stub files).
+	ID string `json:"id,omitempty"`
+	// Expires is the time at which a route created at runtime expires (if any).
+	Expires time.Time `json:"expires,omitzero"`
+	// Layer is the layer directory the stub file comes from (empty for the base root directory).
+	Layer string `json:"layer,omitempty"`
+	// Profile is the name of the profile the route belongs to (empty for base routes).
+	Profile string `json:"profile,omitempty"`
 }
 
 // NewRoute creates a new route structure With default values.
@@ -58,6 +72,80 @@ func (r Route) Match(c echo.Context) bool {
 	return true
 }
 
+// MatchCheck is the result of a route eligibility check against a request.
+type MatchCheck struct {
+	// Check is the checked request property (path, method or query parameter).
+	Check string `json:"check"`
+	// Expected is the value required by the route ("*" for any value).
+	Expected string `json:"expected"`
+	// Actual is the request value (empty if missing).
+	Actual string `json:"actual"`
+	// Passed indicates whether the request satisfies the check.
+	Passed bool `json:"passed"`
+	// Reason is the reason why the check failed (if it did).
+	Reason string `json:"reason,omitempty"`
+}
+
+// Checks checks the route eligibility against a request with the given method,
+// URL path and query parameters, and returns the result of each check.
+func (r Route) Checks(method string, path string, query url.Values) []MatchCheck {
+	checks := []MatchCheck{{Check: "path", Expected: r.Path, Actual: path, Passed: r.Path == path}}
+	if !checks[0].Passed {
+		checks[0].Reason = "path " + path + " is not " + r.Path
+	}
+	check := MatchCheck{Check: "method", Expected: "*", Actual: method, Passed: true}
+	if len(r.Method) > 0 {
+		check.Expected, check.Passed = r.Method, r.Method == method
+		if !check.Passed {
+			check.Reason = "method " + method + " is not " + r.Method
+		}
+	}
+	checks = append(checks, check)
+	for _, qp := range r.QueryParams {
+		check = MatchCheck{Check: "query parameter " + qp.Name, Expected: "*", Actual: query.Get(qp.Name), Passed: true}
+		if len(qp.Value) > 0 {
+			check.Expected = qp.Value
+		}
+		if _, exists := query[qp.Name]; !exists {
+			check.Passed, check.Reason = false, "query parameter "+qp.Name+" is missing"
+		} else if len(qp.Value) > 0 && check.Actual != qp.Value {
+			check.Passed, check.Reason = false, "query parameter "+qp.Name+" is not "+qp.Value
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// Mismatches checks the route eligibility against a request with the given method,
+// URL path and query parameters, and returns the reasons why the route doesn't
+// match (empty if it matches).
+func (r Route) Mismatches(method string, path string, query url.Values) []string {
+	var reasons []string
+	for _, check := range r.Checks(method, path, query) {
+		if !check.Passed {
+			reasons = append(reasons, check.Reason)
+		}
+	}
+	return reasons
+}
+
+// Signature returns the requests matched by the route as a string
+// (method or *, path and required query parameters).
+func (r Route) Signature() string {
+	method := r.Method
+	if len(method) == 0 {
+		method = "*"
+	}
+	signature, sep := method+" "+r.Path, "?"
+	for _, qp := range r.QueryParams {
+		signature, sep = signature+sep+qp.Name, "&"
+		if len(qp.Value) > 0 {
+			signature += "=" + qp.Value
+		}
+	}
+	return signature
+}
+
 // Before reports whether the current route must be evaluated before the other one.
 func (r Route) Before(r2 Route) bool {
 	if r.Path != r2.Path { // Lexicographic order on path
@@ -73,5 +161,13 @@ func (r Route) Before(r2 Route) bool {
 		return len(fmt.Sprintf("%v", r.QueryParams)) >
 			len(fmt.Sprintf("%v", r2.QueryParams))
 	}
-	return r.FilePath < r2.FilePath // Lexicographic order on file path
+	if r.FilePath != r2.FilePath { // Lexicographic order on file path
+		return r.FilePath < r2.FilePath
+	}
+	return r.ID < r2.ID // Lexicographic order on identifier
+}
+
+// IsExpired indicates whether the route has expired at the given time.
+func (r Route) IsExpired(now time.Time) bool {
+	return !r.Expires.IsZero() && !now.Before(r.Expires)
 }
@@ -1,6 +1,10 @@
 package model
 
 import (
+	"github.com/labstack/echo/v4"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"testing"
 )
 
@@ -28,3 +32,44 @@ func TestRoute_Before(t *testing.T) {
 		})
 	}
 }
+
+func TestRoute_Mismatches(t *testing.T) {
+	route := Route{Path: "/items", Method: "GET", QueryParams: []QueryParam{{"page", ""}, {"sort", "asc"}}}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		query  url.Values
+		want   []string
+	}{
+		{"match", "GET", "/items", url.Values{"page": {"1"}, "sort": {"asc"}}, nil},
+		{"path", "GET", "/users", url.Values{"page": {"1"}, "sort": {"asc"}}, []string{"path /users is not /items"}},
+		{"method", "POST", "/items", url.Values{"page": {"1"}, "sort": {"asc"}}, []string{"method POST is not GET"}},
+		{"query", "GET", "/items", url.Values{"sort": {"desc"}},
+			[]string{"query parameter page is missing", "query parameter sort is not asc"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := route.Mismatches(test.method, test.path, test.query); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("want %q, got %q", test.want, got)
+			}
+			// Match must agree with Mismatches
+			req := httptest.NewRequest(test.method, test.path+"?"+test.query.Encode(), nil)
+			if got := route.Match(echo.New().NewContext(req, httptest.NewRecorder())); got != (len(test.want) == 0) {
+				t.Errorf("want match = %v, got %v", len(test.want) == 0, got)
+			}
+		})
+	}
+}
+
+func TestRoute_Checks(t *testing.T) {
+	route := Route{Path: "/items", QueryParams: []QueryParam{{"s", ""}}}
+	want := []MatchCheck{
+		{Check: "path", Expected: "/items", Actual: "/items", Passed: true},
+		{Check: "method", Expected: "*", Actual: "GET", Passed: true},
+		{Check: "query parameter s", Expected: "*", Actual: "", Passed: false, Reason: "query parameter s is missing"},
+	}
+	if got := route.Checks("GET", "/items", url.Values{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
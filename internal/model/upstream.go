@@ -0,0 +1,40 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Upstream is a server to forward unmatched requests to.
+type Upstream struct {
+	// Prefix is the URL path prefix of the requests to forward ("/" for all requests).
+	Prefix string `json:"prefix"`
+	// URL is the upstream server base URL.
+	URL string `json:"url"`
+}
+
+// ParseUpstream validates, parses and returns an upstream value ([<prefix>=]<url>).
+func ParseUpstream(value string) (Upstream, error) {
+	upstream := Upstream{Prefix: "/", URL: value}
+	if prefix, rawURL, found := strings.Cut(value, "="); found && strings.HasPrefix(prefix, "/") {
+		upstream = Upstream{Prefix: prefix, URL: rawURL}
+	}
+	if !upstream.IsValid() {
+		return Upstream{}, errors.New("invalid upstream value")
+	}
+	return upstream, nil
+}
+
+// IsValid indicates whether the current upstream is valid or not.
+func (u Upstream) IsValid() bool {
+	target, err := url.Parse(u.URL)
+	return err == nil && (target.Scheme == "http" || target.Scheme == "https") &&
+		len(target.Host) > 0 && strings.HasPrefix(u.Prefix, "/")
+}
+
+// Matches indicates whether a request on the given URL path must be forwarded to the upstream.
+func (u Upstream) Matches(path string) bool {
+	prefix := strings.TrimSuffix(u.Prefix, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestParseUpstream(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Upstream
+		wantErr bool
+	}{
+		{"url", "http://localhost:8080", Upstream{"/", "http://localhost:8080"}, false},
+		{"prefix", "/api=https://example.com/base", Upstream{"/api", "https://example.com/base"}, false},
+		{"query", "http://localhost/?a=b", Upstream{"/", "http://localhost/?a=b"}, false},
+		{"err/scheme", "ftp://localhost", Upstream{}, true},
+		{"err/host", "/api=http://", Upstream{}, true},
+		{"err/empty", "", Upstream{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			upstream, err := ParseUpstream(test.value)
+			if test.wantErr != (err != nil) {
+				t.Fatalf("want error = %v, got %v (%v)", test.wantErr, err != nil, err)
+			}
+			if upstream != test.want {
+				t.Errorf("want upstream = %v, got %v", test.want, upstream)
+			}
+		})
+	}
+}
+
+func TestConfig_Upstream(t *testing.T) {
+	config := Config{Upstreams: []Upstream{{"/", "http://default"}, {"/api", "http://api"}, {"/api/v2/", "http://v2"}}}
+	tests := []struct {
+		name      string
+		path      string
+		wantURL   string
+		wantFound bool
+	}{
+		{"default", "/items", "http://default", true},
+		{"prefix/exact", "/api", "http://api", true},
+		{"prefix/sub", "/api/items", "http://api", true},
+		{"prefix/partial", "/apis", "http://default", true},
+		{"prefix/longest", "/api/v2/items", "http://v2", true},
+		{"none", "/items", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := config
+			if !test.wantFound {
+				c.Upstreams = c.Upstreams[1:]
+			}
+			upstream, found := c.Upstream(test.path)
+			if found != test.wantFound || upstream.URL != test.wantURL {
+				t.Errorf("want upstream = %q (%v), got %q (%v)", test.wantURL, test.wantFound, upstream.URL, found)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.yaml.in/yaml/v3"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Document is an OpenAPI 3 document (only the fields used by the application).
+type Document struct {
+	// OpenAPI is the OpenAPI specification version.
+	OpenAPI string `json:"openapi"`
+	// Info is the API metadata.
+	Info Info `json:"info"`
+	// Servers are the API servers (their URL path is the base path of API paths).
+	Servers []Server `json:"servers,omitempty"`
+	// Paths are the API paths indexed by path template.
+	Paths map[string]*PathItem `json:"paths"`
+	// Components are reusable objects.
+	Components *Components `json:"components,omitempty"`
+}
+
+// Info is the API metadata.
+type Info struct {
+	// Title is the API title.
+	Title string `json:"title"`
+	// Version is the API document version.
+	Version string `json:"version"`
+}
+
+// Server is an API server.
+type Server struct {
+	// URL is the server URL (possibly relative and with variables).
+	URL string `json:"url"`
+	// Variables are the server URL variables indexed by name.
+	Variables map[string]*ServerVariable `json:"variables,omitempty"`
+}
+
+// ServerVariable is a server URL variable.
+type ServerVariable struct {
+	// Default is the default variable value.
+	Default string `json:"default"`
+}
+
+// PathItem describes the operations available on a path.
+type PathItem struct {
+	// Parameters are the parameters shared by all operations.
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	// Get is the GET operation.
+	Get *Operation `json:"get,omitempty"`
+	// Put is the PUT operation.
+	Put *Operation `json:"put,omitempty"`
+	// Post is the POST operation.
+	Post *Operation `json:"post,omitempty"`
+	// Delete is the DELETE operation.
+	Delete *Operation `json:"delete,omitempty"`
+	// Options is the OPTIONS operation.
+	Options *Operation `json:"options,omitempty"`
+	// Head is the HEAD operation.
+	Head *Operation `json:"head,omitempty"`
+	// Patch is the PATCH operation.
+	Patch *Operation `json:"patch,omitempty"`
+	// Trace is the TRACE operation.
+	Trace *Operation `json:"trace,omitempty"`
+}
+
+// Operations returns the path item operations indexed by HTTP method.
+func (p *PathItem) Operations() map[string]*Operation {
+	ops := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{http.MethodGet: p.Get, http.MethodPut: p.Put,
+		http.MethodPost: p.Post, http.MethodDelete: p.Delete, http.MethodOptions: p.Options,
+		http.MethodHead: p.Head, http.MethodPatch: p.Patch, http.MethodTrace: p.Trace} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// SetOperation sets the operation for the given HTTP method.
+func (p *PathItem) SetOperation(method string, op *Operation) {
+	switch method {
+	case http.MethodGet:
+		p.Get = op
+	case http.MethodPut:
+		p.Put = op
+	case http.MethodPost:
+		p.Post = op
+	case http.MethodDelete:
+		p.Delete = op
+	case http.MethodOptions:
+		p.Options = op
+	case http.MethodHead:
+		p.Head = op
+	case http.MethodPatch:
+		p.Patch = op
+	case http.MethodTrace:
+		p.Trace = op
+	}
+}
+
+// Operation describes an API operation on a path.
+type Operation struct {
+	// OperationID is the operation unique identifier.
+	OperationID string `json:"operationId,omitempty"`
+	// Summary is the operation short summary.
+	Summary string `json:"summary,omitempty"`
+	// Parameters are the operation parameters.
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	// RequestBody is the operation request body.
+	RequestBody *RequestBody `json:"requestBody,omitempty"`
+	// Responses are the operation responses indexed by status code.
+	Responses map[string]*Response `json:"responses"`
+}
+
+// Parameter is an operation parameter.
+type Parameter struct {
+	// Ref is a reference to a parameter component.
+	Ref string `json:"$ref,omitempty"`
+	// Name is the parameter name.
+	Name string `json:"name,omitempty"`
+	// In is the parameter location (path, query, header or cookie).
+	In string `json:"in,omitempty"`
+	// Required indicates whether the parameter is mandatory.
+	Required bool `json:"required,omitempty"`
+	// Schema is the parameter schema.
+	Schema *Schema `json:"schema,omitempty"`
+	// Example is an example parameter value.
+	Example any `json:"example,omitempty"`
+}
+
+// RequestBody is an operation request body.
+type RequestBody struct {
+	// Ref is a reference to a request body component.
+	Ref string `json:"$ref,omitempty"`
+	// Required indicates whether the request body is mandatory.
+	Required bool `json:"required,omitempty"`
+	// Content is the request body content indexed by media type.
+	Content map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Response is an operation response.
+type Response struct {
+	// Ref is a reference to a response component.
+	Ref string `json:"$ref,omitempty"`
+	// Description is the response description.
+	Description string `json:"description"`
+	// Headers are the response headers indexed by name.
+	Headers map[string]*Header `json:"headers,omitempty"`
+	// Content is the response content indexed by media type.
+	Content map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Header is a response header.
+type Header struct {
+	// Schema is the header schema.
+	Schema *Schema `json:"schema,omitempty"`
+	// Example is an example header value.
+	Example any `json:"example,omitempty"`
+}
+
+// MediaType is a content with a given media type.
+type MediaType struct {
+	// Schema is the content schema.
+	Schema *Schema `json:"schema,omitempty"`
+	// Example is an example content.
+	Example any `json:"example,omitempty"`
+	// Examples are example contents indexed by name.
+	Examples map[string]*Example `json:"examples,omitempty"`
+}
+
+// Example is an example value.
+type Example struct {
+	// Ref is a reference to an example component.
+	Ref string `json:"$ref,omitempty"`
+	// Summary is the example short description.
+	Summary string `json:"summary,omitempty"`
+	// Value is the example value.
+	Value any `json:"value,omitempty"`
+}
+
+// Components holds reusable objects.
+type Components struct {
+	// Schemas are reusable schemas indexed by name.
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+	// Responses are reusable responses indexed by name.
+	Responses map[string]*Response `json:"responses,omitempty"`
+	// Parameters are reusable parameters indexed by name.
+	Parameters map[string]*Parameter `json:"parameters,omitempty"`
+	// Examples are reusable examples indexed by name.
+	Examples map[string]*Example `json:"examples,omitempty"`
+	// RequestBodies are reusable request bodies indexed by name.
+	RequestBodies map[string]*RequestBody `json:"requestBodies,omitempty"`
+}
+
+// Load loads an OpenAPI document from a JSON or YAML file and resolves local references.
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("unable to read OpenAPI document")
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if data, err = yamlToJSON(data); err != nil {
+			return nil, errors.New("invalid OpenAPI document: " + err.Error())
+		}
+	}
+	doc := new(Document)
+	if err = json.Unmarshal(data, doc); err != nil {
+		return nil, errors.New("invalid OpenAPI document: " + err.Error())
+	} else if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, errors.New("unsupported OpenAPI version '" + doc.OpenAPI + "'")
+	}
+	if err = doc.resolve(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// yamlToJSON converts a YAML document to JSON.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var value any
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonValue(value))
+}
+
+// jsonValue converts a value decoded from YAML to a value that can be encoded
+// to JSON (mapping keys, e.g. status codes, are converted to strings).
+func jsonValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = jsonValue(item)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return value
+}
@@ -0,0 +1,78 @@
+package openapi
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load("testdata/petstore.json")
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if doc.Info.Title != "Petstore" || len(doc.Paths) != 2 {
+		t.Errorf("want the document to be loaded, got %+v", doc)
+	}
+	list := doc.Paths["/pets"].Get
+	if p := list.Parameters[1]; p.Name != "X-Trace-Id" || p.In != "header" {
+		t.Errorf("want parameter reference to be resolved, got %+v", p)
+	}
+	if items := list.Responses["200"].Content["application/json"].Schema.Items; items == nil || !items.Type.Is("object") {
+		t.Errorf("want schema reference to be resolved, got %+v", items)
+	}
+	if ex := list.Responses["200"].Content["application/json"].Examples["empty"]; ex == nil || ex.Value == nil {
+		t.Errorf("want example reference to be resolved, got %+v", ex)
+	}
+	if res := list.Responses["500"]; res == nil || res.Description != "Error" {
+		t.Errorf("want response reference to be resolved, got %+v", res)
+	}
+	if yamlDoc, err := Load("testdata/petstore.yaml"); err != nil || !reflect.DeepEqual(yamlDoc, doc) {
+		t.Errorf("want the YAML document to be loaded as the JSON one, got %+v (%v)", yamlDoc, err)
+	}
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "sparse.json"), []byte(`{"openapi":"3.0.0","paths":{"/a":null,
+		"/b":{"parameters":[null],"get":{"responses":{"200":null,"201":{"description":"","headers":{"X":null},
+		"content":{"application/json":null,"text/plain":{"examples":{"a":null}}}}}}}}}`), 0644)
+	if sparse, err := Load(filepath.Join(dir, "sparse.json")); err != nil || len(sparse.Paths) != 1 ||
+		len(sparse.Paths["/b"].Get.Responses) != 1 || len(sparse.Paths["/b"].Get.Responses["201"].Content) != 1 {
+		t.Errorf("want null objects to be removed, got %+v (%v)", sparse, err)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "invalid.yaml"), []byte("openapi: [3.0.0"), 0644)
+	for _, path := range []string{"testdata/unknown.json", filepath.Join(dir, "invalid.yaml")} {
+		if _, err = Load(path); err == nil {
+			t.Errorf("want error loading %s", path)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	minimum := 1.0
+	tests := []struct {
+		name   string
+		schema *Schema
+		want   any
+	}{
+		{"nil", nil, nil},
+		{"example", &Schema{Type: SchemaType{"string"}, Example: "ex"}, "ex"},
+		{"default", &Schema{Type: SchemaType{"integer"}, Default: 5.0}, 5.0},
+		{"enum", &Schema{Type: SchemaType{"string"}, Enum: []any{"a", "b"}}, "a"},
+		{"string", &Schema{Type: SchemaType{"string"}}, "string"},
+		{"date", &Schema{Type: SchemaType{"string"}, Format: "date"}, "2006-01-02"},
+		{"integer", &Schema{Type: SchemaType{"integer"}, Minimum: &minimum}, 1.0},
+		{"boolean", &Schema{Type: SchemaType{"boolean"}}, false},
+		{"array", &Schema{Type: SchemaType{"array"}, Items: &Schema{Type: SchemaType{"boolean"}}}, []any{false}},
+		{"object", &Schema{Type: SchemaType{"object"}, Properties: map[string]*Schema{"a": {Type: SchemaType{"string"}}}},
+			map[string]any{"a": "string"}},
+		{"allOf", &Schema{AllOf: []*Schema{{Properties: map[string]*Schema{"a": {Example: 1}}},
+			{Properties: map[string]*Schema{"b": {Example: 2}}}}}, map[string]any{"a": 1, "b": 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Sample(test.schema); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("want sample = %v, got %v", test.want, got)
+			}
+		})
+	}
+}
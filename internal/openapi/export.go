@@ -0,0 +1,270 @@
+package openapi
+
+import (
+	"encoding/json"
+	"gaelgirodon.fr/liege/internal/model"
+	"maps"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// anyMethods are the HTTP methods documented for routes matching any method.
+var anyMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// FromRoutes builds an OpenAPI document describing the given routes. The aliases of
+// a stub file (with or without extension, index) are merged into a single operation
+// on the shortest path, and routes matching any method are documented for common methods.
+func FromRoutes(routes []*model.Route, info Info) *Document {
+	doc := &Document{OpenAPI: "3.0.3", Info: info, Paths: map[string]*PathItem{}}
+	// Find the shortest path for each stub file (or runtime route)
+	canonical := make(map[string]string)
+	for _, r := range routes {
+		key := r.FilePath + "\x00" + r.ID
+		if p, found := canonical[key]; !found || len(r.Path) < len(p) || len(r.Path) == len(p) && r.Path < p {
+			canonical[key] = r.Path
+		}
+	}
+	// Group routes by path and method (keeping the evaluation order)
+	variants := make(map[string]map[string][]*model.Route)
+	for _, r := range routes {
+		if canonical[r.FilePath+"\x00"+r.ID] != r.Path {
+			continue // Alias
+		}
+		if variants[r.Path] == nil {
+			variants[r.Path] = make(map[string][]*model.Route)
+		}
+		methods := []string{r.Method}
+		if len(r.Method) == 0 {
+			methods = anyMethods
+		}
+		for _, method := range methods {
+			variants[r.Path][method] = append(variants[r.Path][method], r)
+		}
+	}
+	// Build operations
+	for path, byMethod := range variants {
+		item := &PathItem{}
+		for method, rs := range byMethod {
+			item.SetOperation(method, operation(rs))
+		}
+		doc.Paths[path] = item
+	}
+	return doc
+}
+
+// operation builds an operation from the routes serving a path for a method.
+func operation(routes []*model.Route) *Operation {
+	op := &Operation{Responses: map[string]*Response{}}
+	var files []string
+	// Query parameters (required if required by all routes)
+	params := make(map[string]*Parameter)
+	var names []string
+	counts := make(map[string]int)
+	for _, r := range routes {
+		files = append(files, source(r))
+		for _, qp := range r.QueryParams {
+			if _, found := params[qp.Name]; !found {
+				params[qp.Name] = &Parameter{Name: qp.Name, In: "query", Schema: &Schema{Type: SchemaType{"string"}}}
+				names = append(names, qp.Name)
+			}
+			if len(qp.Value) > 0 && params[qp.Name].Example == nil {
+				params[qp.Name].Example = qp.Value
+			}
+			counts[qp.Name]++
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		params[name].Required = counts[name] == len(routes)
+		op.Parameters = append(op.Parameters, params[name])
+	}
+	// Responses
+	firstFiles := make(map[*MediaType]string)
+	for _, r := range routes {
+		code := strconv.Itoa(r.Code)
+		res, found := op.Responses[code]
+		if !found {
+			res = &Response{Description: http.StatusText(r.Code)}
+			op.Responses[code] = res
+		}
+		if len(r.Content) == 0 {
+			continue
+		}
+		if res.Content == nil {
+			res.Content = map[string]*MediaType{}
+		}
+		mediaType, _, _ := mime.ParseMediaType(r.ContentType)
+		if len(mediaType) == 0 {
+			mediaType = "application/octet-stream"
+		}
+		mt, found := res.Content[mediaType]
+		value, schema := sampleOf(r.Content, mediaType)
+		if !found {
+			mt = &MediaType{Schema: schema, Example: value}
+			res.Content[mediaType] = mt
+			firstFiles[mt] = source(r)
+		} else if value != nil {
+			mt.Schema = mergeSchemas(mt.Schema, schema)
+			if mt.Examples == nil && mt.Example != nil {
+				mt.Examples = map[string]*Example{firstFiles[mt]: {Value: mt.Example}}
+			}
+			mt.Example = nil
+			if mt.Examples == nil {
+				mt.Examples = map[string]*Example{}
+			}
+			mt.Examples[source(r)] = &Example{Value: value}
+		}
+	}
+	op.Summary = "Served from " + strings.Join(unique(files), ", ")
+	return op
+}
+
+// source returns the stub file path of a route, or its identifier for a runtime route.
+func source(r *model.Route) string {
+	if len(r.FilePath) == 0 && len(r.ID) > 0 {
+		return "route " + r.ID
+	}
+	return r.FilePath
+}
+
+// sampleOf returns the example value and the schema of a stub file content.
+func sampleOf(content []byte, mediaType string) (any, *Schema) {
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		var value any
+		if err := json.Unmarshal(content, &value); err == nil {
+			return value, SchemaOf(value)
+		}
+	}
+	if utf8.Valid(content) {
+		return string(content), &Schema{Type: SchemaType{"string"}}
+	}
+	return nil, &Schema{Type: SchemaType{"string"}, Format: "binary"}
+}
+
+// SchemaOf infers a schema from a JSON value.
+func SchemaOf(value any) *Schema {
+	switch v := value.(type) {
+	case map[string]any:
+		s := &Schema{Type: SchemaType{"object"}, Properties: map[string]*Schema{}}
+		for name, prop := range v {
+			s.Properties[name] = SchemaOf(prop)
+		}
+		return s
+	case []any:
+		s := &Schema{Type: SchemaType{"array"}}
+		if len(v) > 0 {
+			s.Items = SchemaOf(v[0])
+		} else {
+			s.Items = &Schema{}
+		}
+		return s
+	case string:
+		return &Schema{Type: SchemaType{"string"}}
+	case float64:
+		if v == float64(int64(v)) {
+			return &Schema{Type: SchemaType{"integer"}}
+		}
+		return &Schema{Type: SchemaType{"number"}}
+	case bool:
+		return &Schema{Type: SchemaType{"boolean"}}
+	}
+	return &Schema{Nullable: true}
+}
+
+// mergeSchemas returns a schema matching the values of both inferred schemas: object
+// properties and array items are merged, integers are widened to numbers, null values
+// make the schema nullable and values of different types are allowed with anyOf.
+func mergeSchemas(a, b *Schema) *Schema {
+	switch {
+	case a == nil || reflect.DeepEqual(*a, Schema{}): // Any value (e.g. items of an empty array)
+		return b
+	case b == nil || reflect.DeepEqual(*b, Schema{}):
+		return a
+	case isNullSchema(a):
+		return nullable(b)
+	case isNullSchema(b):
+		return nullable(a)
+	}
+	merged := alternatives(a)
+	for _, alt := range alternatives(b) {
+		if i := slices.IndexFunc(merged, func(s *Schema) bool { return kindOf(s) == kindOf(alt) }); i >= 0 {
+			merged[i] = mergeSameKind(merged[i], alt)
+		} else {
+			merged = append(merged, alt)
+		}
+	}
+	if len(merged) == 1 {
+		return merged[0]
+	}
+	return &Schema{AnyOf: merged, Nullable: a.Nullable || b.Nullable}
+}
+
+// mergeSameKind merges two inferred schemas of the same kind (see kindOf).
+func mergeSameKind(a, b *Schema) *Schema {
+	s := &Schema{Type: a.Type, Nullable: a.Nullable || b.Nullable}
+	if a.Format == b.Format {
+		s.Format = a.Format
+	}
+	switch {
+	case a.Type.Is("object"):
+		s.Properties = maps.Clone(a.Properties)
+		for name, prop := range b.Properties {
+			s.Properties[name] = mergeSchemas(s.Properties[name], prop)
+		}
+	case a.Type.Is("array"):
+		s.Items = mergeSchemas(a.Items, b.Items)
+	case !slices.Equal(a.Type, b.Type): // Integer and number
+		s.Type = SchemaType{"number"}
+	}
+	return s
+}
+
+// isNullSchema indicates whether the inferred schema only allows the null value.
+func isNullSchema(s *Schema) bool {
+	return s.Nullable && len(s.Type) == 0 && len(s.AnyOf) == 0
+}
+
+// nullable returns a copy of the schema allowing the null value.
+func nullable(s *Schema) *Schema {
+	c := *s
+	c.Nullable = true
+	return &c
+}
+
+// alternatives returns the schemas allowed by an inferred schema (anyOf or itself).
+func alternatives(s *Schema) []*Schema {
+	if len(s.AnyOf) > 0 {
+		return slices.Clone(s.AnyOf)
+	}
+	return []*Schema{s}
+}
+
+// kindOf returns the kind of value allowed by an inferred schema
+// (its type, with integers and numbers of the same kind).
+func kindOf(s *Schema) string {
+	if s.Type.Is("integer") {
+		return "number"
+	} else if len(s.Type) > 0 {
+		return s.Type[0]
+	}
+	return ""
+}
+
+// unique returns the given values without duplicates (keeping the order).
+func unique(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
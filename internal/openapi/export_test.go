@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"gaelgirodon.fr/liege/internal/model"
+	"testing"
+)
+
+func TestFromRoutes(t *testing.T) {
+	newRoute := func(filePath, path, method string, code int, content string, params ...model.QueryParam) *model.Route {
+		r := model.NewRoute()
+		r.FilePath, r.Path, r.Method, r.Code, r.Content, r.QueryParams = filePath, path, method, code, []byte(content), params
+		r.ContentType = "application/json"
+		return &r
+	}
+	routes := []*model.Route{
+		newRoute("users/index__qid=1.json", "/users/index.json", "GET", 200, `{"id":1}`, model.QueryParam{Name: "id", Value: "1"}),
+		newRoute("users/index__qid=1.json", "/users/index", "GET", 200, `{"id":1}`, model.QueryParam{Name: "id", Value: "1"}),
+		newRoute("users/index__qid=1.json", "/users", "GET", 200, `{"id":1}`, model.QueryParam{Name: "id", Value: "1"}),
+		newRoute("users/index.json", "/users/index.json", "GET", 200, `[{"id":1}]`),
+		newRoute("users/index.json", "/users/index", "GET", 200, `[{"id":1}]`),
+		newRoute("users/index.json", "/users", "GET", 200, `[{"id":1}]`),
+		newRoute("ping", "/ping", "", 204, ""),
+	}
+	doc := FromRoutes(routes, Info{Title: "Test", Version: "1"})
+	if len(doc.Paths) != 2 {
+		t.Fatalf("want 2 paths (aliases merged), got %d", len(doc.Paths))
+	}
+	op := doc.Paths["/users"].Get
+	if op == nil || len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || op.Parameters[0].Required {
+		t.Fatalf("want GET /users with an optional id parameter, got %+v", op)
+	}
+	mt := op.Responses["200"].Content["application/json"]
+	if mt.Example != nil || len(mt.Examples) != 2 || mt.Examples["users/index.json"] == nil {
+		t.Errorf("want an example per stub file, got %+v", mt)
+	}
+	if len(mt.Schema.AnyOf) != 2 || !mt.Schema.AnyOf[0].Type.Is("object") || !mt.Schema.AnyOf[1].Type.Is("array") {
+		t.Errorf("want the schemas of all stub files, got %+v", mt.Schema)
+	}
+	for name, example := range mt.Examples {
+		if violations := mt.Schema.Validate(example.Value, name); len(violations) > 0 {
+			t.Errorf("want examples to match the schema, got %v", violations)
+		}
+	}
+	ops := doc.Paths["/ping"].Operations()
+	if len(ops) != len(anyMethods) || ops["DELETE"].Responses["204"] == nil {
+		t.Errorf("want /ping to be documented for common methods, got %+v", ops)
+	}
+}
+
+func Test_mergeSchemas(t *testing.T) {
+	values := []any{
+		map[string]any{"id": 1.0, "tags": []any{}, "parent": nil},
+		map[string]any{"id": 1.5, "tags": []any{"a"}, "parent": map[string]any{"id": 1.0}, "name": "b"},
+		nil,
+	}
+	var s *Schema
+	for _, value := range values {
+		s = mergeSchemas(s, SchemaOf(value))
+	}
+	if !s.Type.Is("object") || !s.Nullable || !s.Properties["id"].Type.Is("number") ||
+		!s.Properties["tags"].Items.Type.Is("string") || !s.Properties["parent"].Nullable ||
+		!s.Properties["parent"].Type.Is("object") || !s.Properties["name"].Type.Is("string") {
+		t.Errorf("want schemas to be merged, got %+v", s)
+	}
+	for i, value := range values {
+		if violations := s.Validate(value, "value"); len(violations) > 0 {
+			t.Errorf("want value #%d to match the merged schema, got %v", i, violations)
+		}
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	s := SchemaOf(map[string]any{"id": 1.0, "price": 1.5, "tags": []any{"a"}, "ok": true, "none": nil})
+	if !s.Type.Is("object") || !s.Properties["id"].Type.Is("integer") || !s.Properties["price"].Type.Is("number") ||
+		!s.Properties["tags"].Items.Type.Is("string") || !s.Properties["ok"].Type.Is("boolean") ||
+		!s.Properties["none"].Nullable {
+		t.Errorf("want schema to be inferred, got %+v", s)
+	}
+}
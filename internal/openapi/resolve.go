@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"errors"
+	"strings"
+)
+
+// maxRefDepth is the maximum number of chained references.
+const maxRefDepth = 32
+
+// resolver replaces local references (#/components/...) with the referenced objects.
+type resolver struct {
+	// components are the document components.
+	components *Components
+	// visited are the schemas already resolved (to handle recursive schemas).
+	visited map[*Schema]bool
+}
+
+// resolve replaces local references in the document with the referenced objects
+// (and removes null objects, e.g. paths, responses or media types).
+func (doc *Document) resolve() (err error) {
+	if doc.Components == nil {
+		doc.Components = &Components{}
+	}
+	r := &resolver{components: doc.Components, visited: make(map[*Schema]bool)}
+	for name, s := range doc.Components.Schemas {
+		if doc.Components.Schemas[name], err = r.schema(s); err != nil {
+			return err
+		}
+	}
+	for template, item := range doc.Paths {
+		if item == nil {
+			delete(doc.Paths, template)
+			continue
+		}
+		if item.Parameters, err = r.parameters(item.Parameters); err != nil {
+			return err
+		}
+		for _, op := range item.Operations() {
+			if op.Parameters, err = r.parameters(op.Parameters); err != nil {
+				return err
+			}
+			if op.RequestBody, err = r.requestBody(op.RequestBody); err != nil {
+				return err
+			}
+			for code, res := range op.Responses {
+				if res, err = r.response(res); err != nil {
+					return err
+				} else if res == nil {
+					delete(op.Responses, code)
+				} else {
+					op.Responses[code] = res
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// refName returns the component name from a local reference with the given prefix.
+func refName(ref string, prefix string) (string, error) {
+	if !strings.HasPrefix(ref, prefix) {
+		return "", errors.New("unsupported reference '" + ref + "'")
+	}
+	name := strings.TrimPrefix(ref, prefix)
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(name), nil
+}
+
+// schema resolves a schema and its sub-schemas.
+func (r *resolver) schema(s *Schema) (*Schema, error) {
+	for depth := 0; s != nil && len(s.Ref) > 0; depth++ {
+		name, err := refName(s.Ref, "#/components/schemas/")
+		if err != nil {
+			return nil, err
+		} else if target, found := r.components.Schemas[name]; !found || depth > maxRefDepth {
+			return nil, errors.New("unable to resolve reference '" + s.Ref + "'")
+		} else {
+			s = target
+		}
+	}
+	if s == nil || r.visited[s] {
+		return s, nil
+	}
+	r.visited[s] = true
+	var err error
+	for name, prop := range s.Properties {
+		if s.Properties[name], err = r.schema(prop); err != nil {
+			return nil, err
+		}
+	}
+	if s.Items, err = r.schema(s.Items); err != nil {
+		return nil, err
+	}
+	for _, list := range [][]*Schema{s.AllOf, s.AnyOf, s.OneOf} {
+		for i, sub := range list {
+			if list[i], err = r.schema(sub); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// parameters resolves a list of parameters (null parameters are removed).
+func (r *resolver) parameters(params []*Parameter) (_ []*Parameter, err error) {
+	resolved := params[:0]
+	for _, p := range params {
+		for depth := 0; p != nil && len(p.Ref) > 0; depth++ {
+			name, err := refName(p.Ref, "#/components/parameters/")
+			if err != nil {
+				return nil, err
+			} else if target, found := r.components.Parameters[name]; !found || depth > maxRefDepth {
+				return nil, errors.New("unable to resolve reference '" + p.Ref + "'")
+			} else {
+				p = target
+			}
+		}
+		if p == nil {
+			continue
+		}
+		if p.Schema, err = r.schema(p.Schema); err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// requestBody resolves a request body.
+func (r *resolver) requestBody(body *RequestBody) (_ *RequestBody, err error) {
+	for depth := 0; body != nil && len(body.Ref) > 0; depth++ {
+		name, err := refName(body.Ref, "#/components/requestBodies/")
+		if err != nil {
+			return nil, err
+		} else if target, found := r.components.RequestBodies[name]; !found || depth > maxRefDepth {
+			return nil, errors.New("unable to resolve reference '" + body.Ref + "'")
+		} else {
+			body = target
+		}
+	}
+	if body != nil {
+		err = r.content(body.Content)
+	}
+	return body, err
+}
+
+// response resolves a response.
+func (r *resolver) response(res *Response) (_ *Response, err error) {
+	for depth := 0; res != nil && len(res.Ref) > 0; depth++ {
+		name, err := refName(res.Ref, "#/components/responses/")
+		if err != nil {
+			return nil, err
+		} else if target, found := r.components.Responses[name]; !found || depth > maxRefDepth {
+			return nil, errors.New("unable to resolve reference '" + res.Ref + "'")
+		} else {
+			res = target
+		}
+	}
+	if res == nil {
+		return nil, nil
+	}
+	for name, h := range res.Headers {
+		if h == nil {
+			delete(res.Headers, name)
+			continue
+		}
+		if h.Schema, err = r.schema(h.Schema); err != nil {
+			return nil, err
+		}
+	}
+	return res, r.content(res.Content)
+}
+
+// content resolves media types schemas and examples (null media types and examples are removed).
+func (r *resolver) content(content map[string]*MediaType) (err error) {
+	for mediaType, mt := range content {
+		if mt == nil {
+			delete(content, mediaType)
+			continue
+		}
+		if mt.Schema, err = r.schema(mt.Schema); err != nil {
+			return err
+		}
+		for name, ex := range mt.Examples {
+			for depth := 0; ex != nil && len(ex.Ref) > 0; depth++ {
+				exName, err := refName(ex.Ref, "#/components/examples/")
+				if err != nil {
+					return err
+				} else if target, found := r.components.Examples[exName]; !found || depth > maxRefDepth {
+					return errors.New("unable to resolve reference '" + ex.Ref + "'")
+				} else {
+					ex = target
+				}
+			}
+			if ex == nil {
+				delete(mt.Examples, name)
+			} else {
+				mt.Examples[name] = ex
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package openapi
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Schema is a JSON schema (only the keywords used by the application).
+type Schema struct {
+	// Ref is a reference to a schema component.
+	Ref string `json:"$ref,omitempty"`
+	// Type is the value type (or the list of allowed types).
+	Type SchemaType `json:"type,omitempty"`
+	// Format is the value format (e.g. date-time).
+	Format string `json:"format,omitempty"`
+	// Nullable indicates whether the null value is allowed (OpenAPI 3.0).
+	Nullable bool `json:"nullable,omitempty"`
+	// Enum is the list of allowed values.
+	Enum []any `json:"enum,omitempty"`
+	// Default is the default value.
+	Default any `json:"default,omitempty"`
+	// Example is an example value.
+	Example any `json:"example,omitempty"`
+	// Properties are the object properties schemas indexed by name.
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	// Required is the list of required object properties.
+	Required []string `json:"required,omitempty"`
+	// Items is the array items schema.
+	Items *Schema `json:"items,omitempty"`
+	// AllOf is a list of schemas the value must match.
+	AllOf []*Schema `json:"allOf,omitempty"`
+	// AnyOf is a list of schemas the value must match at least one of.
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	// OneOf is a list of schemas the value must match exactly one of.
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	// Minimum is the minimum numeric value.
+	Minimum *float64 `json:"minimum,omitempty"`
+	// Maximum is the maximum numeric value.
+	Maximum *float64 `json:"maximum,omitempty"`
+	// MinLength is the minimum string length.
+	MinLength *int `json:"minLength,omitempty"`
+	// MaxLength is the maximum string length.
+	MaxLength *int `json:"maxLength,omitempty"`
+	// Pattern is the regular expression a string must match.
+	Pattern string `json:"pattern,omitempty"`
+	// MinItems is the minimum array length.
+	MinItems *int `json:"minItems,omitempty"`
+	// MaxItems is the maximum array length.
+	MaxItems *int `json:"maxItems,omitempty"`
+}
+
+// SchemaType is a schema type, either a single type or a list of types.
+type SchemaType []string
+
+// UnmarshalJSON decodes a single type or a list of types.
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaType{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// MarshalJSON encodes a single type as a string and multiple types as a list.
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Is indicates whether the given type is allowed.
+func (t SchemaType) Is(name string) bool {
+	return slices.Contains(t, name)
+}
+
+// Sample returns a sample value matching the schema, built from examples,
+// default values or allowed values if available, or from the type otherwise.
+func Sample(s *Schema) any {
+	return sample(s, 0)
+}
+
+// sample returns a sample value matching the schema, up to a maximum depth.
+func sample(s *Schema, depth int) any {
+	switch {
+	case s == nil || depth > 8:
+		return nil
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.AllOf) > 0:
+		merged := map[string]any{}
+		for _, sub := range s.AllOf {
+			if obj, ok := sample(sub, depth+1).(map[string]any); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	case len(s.OneOf) > 0:
+		return sample(s.OneOf[0], depth+1)
+	case len(s.AnyOf) > 0:
+		return sample(s.AnyOf[0], depth+1)
+	case s.Type.Is("object") || len(s.Properties) > 0:
+		obj := map[string]any{}
+		for name, prop := range s.Properties {
+			obj[name] = sample(prop, depth+1)
+		}
+		return obj
+	case s.Type.Is("array"):
+		return []any{sample(s.Items, depth+1)}
+	case s.Type.Is("string"):
+		switch s.Format {
+		case "date":
+			return "2006-01-02"
+		case "date-time":
+			return "2006-01-02T15:04:05Z"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		}
+		return "string"
+	case s.Type.Is("integer"), s.Type.Is("number"):
+		if s.Minimum != nil {
+			return *s.Minimum
+		}
+		return 0
+	case s.Type.Is("boolean"):
+		return false
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ignoredHeaderParams are header parameters ignored by the specification.
+var ignoredHeaderParams = map[string]bool{"Accept": true, "Content-Type": true, "Authorization": true}
+
+// ValidateRequest checks a request (with its body) against the operation of the
+// document matching its path and method, and returns the list of violations (empty
+// if the request is valid or if the document doesn't define a matching operation).
+func (doc *Document) ValidateRequest(req *http.Request, body []byte) []string {
+	_, item, pathParams := doc.findPath(req.URL.Path)
+	if item == nil {
+		return nil
+	}
+	op := item.Operations()[req.Method]
+	if op == nil {
+		return nil
+	}
+	var violations []string
+	// Parameters (operation parameters override path item parameters)
+	params := make(map[string]*Parameter)
+	var keys []string
+	for _, p := range append(append([]*Parameter{}, item.Parameters...), op.Parameters...) {
+		if p != nil {
+			if _, found := params[p.In+":"+p.Name]; !found {
+				keys = append(keys, p.In+":"+p.Name)
+			}
+			params[p.In+":"+p.Name] = p
+		}
+	}
+	query := req.URL.Query()
+	for _, key := range keys {
+		p := params[key]
+		var values []string
+		switch p.In {
+		case "path":
+			if value, found := pathParams[p.Name]; found {
+				values = []string{value}
+			}
+		case "query":
+			values = query[p.Name]
+		case "header":
+			if ignoredHeaderParams[http.CanonicalHeaderKey(p.Name)] {
+				continue
+			}
+			values = req.Header.Values(p.Name)
+		default:
+			continue
+		}
+		desc := p.In + " parameter " + p.Name
+		if len(values) == 0 {
+			if p.Required {
+				violations = append(violations, desc+" is required")
+			}
+			continue
+		}
+		violations = append(violations, p.Schema.Validate(paramValue(values, p.Schema), desc)...)
+	}
+	// Body
+	if op.RequestBody != nil {
+		violations = append(violations, validateBody(op.RequestBody, req.Header.Get("Content-Type"), body)...)
+	}
+	return violations
+}
+
+// findPath returns the path template and item matching an URL path (under the base
+// path of a server) with the path parameters values.
+func (doc *Document) findPath(urlPath string) (template string, item *PathItem, params map[string]string) {
+	for _, base := range doc.basePaths() {
+		if rest, found := strings.CutPrefix(urlPath, base); found && (len(rest) == 0 || rest[0] == '/') {
+			return doc.findTemplate(rest)
+		}
+	}
+	return
+}
+
+// basePaths returns the base paths of the document servers (longest first),
+// or the root path if no server is defined.
+func (doc *Document) basePaths() []string {
+	var paths []string
+	for _, server := range doc.Servers {
+		rawURL := server.URL
+		for name, variable := range server.Variables {
+			if variable != nil {
+				rawURL = strings.ReplaceAll(rawURL, "{"+name+"}", variable.Default)
+			}
+		}
+		if u, err := url.Parse(rawURL); err == nil {
+			paths = append(paths, strings.TrimSuffix(u.Path, "/"))
+		}
+	}
+	if len(paths) == 0 {
+		return []string{""}
+	}
+	sort.Slice(paths, func(i, j int) bool { return len(paths[i]) > len(paths[j]) })
+	return paths
+}
+
+// findTemplate returns the path template and item matching an URL path relative to
+// the base path with the path parameters values (templates with more literal segments
+// are preferred).
+func (doc *Document) findTemplate(urlPath string) (template string, item *PathItem, params map[string]string) {
+	segments := strings.Split(strings.Trim(urlPath, "/"), "/")
+	best := -1
+	for t, pathItem := range doc.Paths {
+		tSegments := strings.Split(strings.Trim(t, "/"), "/")
+		if len(tSegments) != len(segments) || pathItem == nil {
+			continue
+		}
+		literals, values, ok := 0, map[string]string{}, true
+		for i, seg := range tSegments {
+			if name, isParam := strings.CutPrefix(seg, "{"); isParam && strings.HasSuffix(name, "}") {
+				values[strings.TrimSuffix(name, "}")] = segments[i]
+			} else if seg == segments[i] {
+				literals++
+			} else {
+				ok = false
+				break
+			}
+		}
+		if ok && (literals > best || literals == best && t < template) {
+			template, item, params, best = t, pathItem, values, literals
+		}
+	}
+	return
+}
+
+// paramValue converts raw parameter values to a value of the schema type.
+func paramValue(values []string, s *Schema) any {
+	if s != nil && s.Type.Is("array") {
+		var items []any
+		for _, v := range values {
+			for _, item := range strings.Split(v, ",") {
+				items = append(items, scalarValue(item, s.Items))
+			}
+		}
+		return items
+	}
+	return scalarValue(values[0], s)
+}
+
+// scalarValue converts a raw parameter value to a value of the schema type
+// (the raw value is kept if it cannot be converted).
+func scalarValue(value string, s *Schema) any {
+	if s == nil {
+		return value
+	}
+	switch {
+	case s.Type.Is("integer"), s.Type.Is("number"):
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case s.Type.Is("boolean"):
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// validateBody checks a request body against the request body definition.
+func validateBody(def *RequestBody, contentType string, body []byte) []string {
+	if len(body) == 0 {
+		if def.Required {
+			return []string{"request body is required"}
+		}
+		return nil
+	} else if len(def.Content) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	mt, found := def.Content[mediaType]
+	if !found {
+		if mt, found = def.Content[strings.Split(mediaType, "/")[0]+"/*"]; !found {
+			mt, found = def.Content["*/*"]
+		}
+	}
+	if !found {
+		return []string{"request content type '" + mediaType + "' is not allowed"}
+	} else if mt == nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []string{"request body is not valid JSON"}
+	}
+	return mt.Schema.Validate(value, "request body")
+}
+
+// Validate checks a JSON value against the schema and returns the list of
+// violations, each one prefixed with the given description of the value.
+func (s *Schema) Validate(value any, desc string) []string {
+	if s == nil {
+		return nil
+	}
+	if value == nil {
+		if s.Nullable || len(s.Type) == 0 || s.Type.Is("null") {
+			return nil
+		}
+		return []string{desc + " must not be null"}
+	}
+	if len(s.Type) > 0 && !s.Type.Is(typeOf(value)) && !(typeOf(value) == "integer" && s.Type.Is("number")) {
+		return []string{fmt.Sprintf("%s must be of type %s", desc, strings.Join(s.Type, " or "))}
+	}
+	var violations []string
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		violations = append(violations, desc+" must be one of the allowed values")
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, found := v[name]; !found {
+				violations = append(violations, desc+"."+name+" is required")
+			}
+		}
+		for _, name := range sortedNames(v) {
+			violations = append(violations, s.Properties[name].Validate(v[name], desc+"."+name)...)
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			violations = append(violations, fmt.Sprintf("%s must have at least %d items", desc, *s.MinItems))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			violations = append(violations, fmt.Sprintf("%s must have at most %d items", desc, *s.MaxItems))
+		}
+		for i, item := range v {
+			violations = append(violations, s.Items.Validate(item, fmt.Sprintf("%s[%d]", desc, i))...)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			violations = append(violations, fmt.Sprintf("%s must have at least %d characters", desc, *s.MinLength))
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			violations = append(violations, fmt.Sprintf("%s must have at most %d characters", desc, *s.MaxLength))
+		}
+		if len(s.Pattern) > 0 {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+				violations = append(violations, desc+" must match pattern "+s.Pattern)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			violations = append(violations, fmt.Sprintf("%s must be greater than or equal to %v", desc, *s.Minimum))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			violations = append(violations, fmt.Sprintf("%s must be less than or equal to %v", desc, *s.Maximum))
+		}
+	}
+	for _, sub := range s.AllOf {
+		violations = append(violations, sub.Validate(value, desc)...)
+	}
+	if len(s.AnyOf) > 0 && countValid(s.AnyOf, value) == 0 {
+		violations = append(violations, desc+" must match at least one schema (anyOf)")
+	}
+	if len(s.OneOf) > 0 && countValid(s.OneOf, value) != 1 {
+		violations = append(violations, desc+" must match exactly one schema (oneOf)")
+	}
+	return violations
+}
+
+// typeOf returns the JSON schema type of a JSON value.
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// containsValue indicates whether a list of JSON values contains the given value.
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if fmt.Sprint(v) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// countValid returns the number of schemas the value is valid against.
+func countValid(schemas []*Schema, value any) (count int) {
+	for _, s := range schemas {
+		if len(s.Validate(value, "")) == 0 {
+			count++
+		}
+	}
+	return
+}
+
+// sortedNames returns the property names of a JSON object sorted in ascending order.
+func sortedNames(obj map[string]any) []string {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
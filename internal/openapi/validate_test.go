@@ -0,0 +1,61 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDocument_ValidateRequest(t *testing.T) {
+	doc, err := Load("testdata/petstore.json")
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   []string
+	}{
+		{"ok/list", "GET", "/pets?limit=10", "", nil},
+		{"ok/get", "GET", "/pets/1", "", nil},
+		{"ok/create", "POST", "/pets", `{"id":1,"name":"Rex","tag":null}`, nil},
+		{"ok/undefined-path", "GET", "/owners", "", nil},
+		{"ok/undefined-method", "DELETE", "/pets", "", nil},
+		{"err/path-param", "GET", "/pets/rex", "", []string{"path parameter petId must be of type integer"}},
+		{"err/query-param", "GET", "/pets?limit=500", "",
+			[]string{"query parameter limit must be less than or equal to 100"}},
+		{"err/body-required", "POST", "/pets", "", []string{"request body is required"}},
+		{"err/body-json", "POST", "/pets", `{`, []string{"request body is not valid JSON"}},
+		{"err/body-schema", "POST", "/pets", `{"id":0,"tag":1}`, []string{"request body.name is required",
+			"request body.id must be greater than or equal to 1", "request body.tag must be of type string"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			if len(test.body) > 0 {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if got := doc.ValidateRequest(req, []byte(test.body)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+	// Paths are relative to the servers base path
+	doc.Servers = []Server{{URL: "https://{host}/{version}/", Variables: map[string]*ServerVariable{
+		"host": {Default: "api.example.com"}, "version": {Default: "v1"}}}}
+	for target, want := range map[string]int{"/v1/pets/rex": 1, "/pets/rex": 0, "/v1pets/rex": 0} {
+		if got := doc.ValidateRequest(httptest.NewRequest(http.MethodGet, target, nil), nil); len(got) != want {
+			t.Errorf("want %d violation(s) for %s, got %q", want, target, got)
+		}
+	}
+	doc.Servers = nil
+	req := httptest.NewRequest(http.MethodPost, "/pets", strings.NewReader("id=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if got := doc.ValidateRequest(req, []byte("id=1")); len(got) != 1 {
+		t.Errorf("want content type violation, got %q", got)
+	}
+}
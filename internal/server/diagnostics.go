@@ -0,0 +1,135 @@
+package server
+
+import (
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	paths "path"
+	"sort"
+	"strings"
+)
+
+// maxClosestRoutes is the maximum number of closest routes (by path)
+// listed in a diagnostic response.
+const maxClosestRoutes = 3
+
+// diagnostic is the body of a diagnostic response for an unmatched request.
+type diagnostic struct {
+	// Message is the error message.
+	Message string `json:"message"`
+	// Closest are the routes with the closest paths.
+	Closest []nearMiss `json:"closest"`
+	// Mismatches are the routes with the request path but another method or query.
+	Mismatches []nearMiss `json:"mismatches"`
+	// Hints are suggestions about path aliases (trailing slash and extension).
+	Hints []string `json:"hints"`
+}
+
+// nearMiss is a route almost matching a request.
+type nearMiss struct {
+	// Path is the URL path of the route.
+	Path string `json:"path"`
+	// Method is the required HTTP method of the route (empty for any).
+	Method string `json:"method"`
+	// FilePath is the path to the stub file of the route (if any).
+	FilePath string `json:"file_path,omitempty"`
+	// ID is the identifier of the runtime route (if any).
+	ID string `json:"id,omitempty"`
+	// Distance is the edit distance between the route and the request paths.
+	Distance int `json:"distance,omitempty"`
+	// Mismatches are the reasons why the route doesn't match the request.
+	Mismatches []string `json:"mismatches,omitempty"`
+}
+
+// diagnose lists the routes almost matching an unmatched request.
+func diagnose(routes []*model.Route, method string, path string, query map[string][]string) diagnostic {
+	d := diagnostic{Message: "no route matches " + method + " " + path,
+		Closest: []nearMiss{}, Mismatches: []nearMiss{}, Hints: []string{}}
+	var hints []string
+	seen := make(map[string]bool)
+	for _, route := range routes {
+		miss := nearMiss{Path: route.Path, Method: route.Method, FilePath: route.FilePath, ID: route.ID}
+		if route.Path == path {
+			miss.Mismatches = route.Mismatches(method, path, query)
+			d.Mismatches = append(d.Mismatches, miss)
+			continue
+		}
+		if hint := aliasHint(path, route); len(hint) > 0 && !seen[hint] {
+			seen[hint] = true
+			hints = append(hints, hint)
+		}
+		if !seen[route.Path] {
+			seen[route.Path] = true
+			miss.Distance = editDistance(path, route.Path)
+			d.Closest = append(d.Closest, miss)
+		}
+	}
+	if len(d.Mismatches) == 0 { // Aliases are only relevant if the path is unknown
+		d.Hints = append(d.Hints, hints...)
+	}
+	sort.SliceStable(d.Closest, func(i, j int) bool {
+		return d.Closest[i].Distance < d.Closest[j].Distance
+	})
+	d.Closest = d.Closest[:min(len(d.Closest), maxClosestRoutes)]
+	return d
+}
+
+// aliasHint returns a hint if the route path is another alias of the same
+// stub file path as the request path (trailing slash, extension or index file).
+func aliasHint(path string, route *model.Route) string {
+	if aliasKey(path) != aliasKey(route.Path) {
+		return ""
+	}
+	source := route.FilePath
+	if len(source) == 0 {
+		source = "route " + route.ID
+	}
+	return "stub files are served with or without their extension and index files on their directory path" +
+		" (without trailing slash), try " + route.Path + " (" + source + ")"
+}
+
+// aliasKey returns the URL path without trailing slash, extension and index file name.
+func aliasKey(path string) string {
+	path = strings.TrimSuffix(path, "/")
+	path = strings.TrimSuffix(path, paths.Ext(path))
+	return strings.TrimSuffix(path, "/index")
+}
+
+// editDistance computes the Levenshtein distance between two strings.
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// notFound responds to an unmatched request with a 404 status,
+// and lists the routes almost matching it if diagnostics are enabled.
+func (s *StubServer) notFound(c echo.Context) error {
+	if !s.config().Diagnostics {
+		return c.NoContent(http.StatusNotFound)
+	}
+	req := c.Request()
+	d := diagnose(s.getProfileRoutes(req.Header.Get(profileHeader)), req.Method, req.URL.Path, c.QueryParams())
+	closest := make([]string, 0, len(d.Closest))
+	for _, miss := range d.Closest {
+		closest = append(closest, miss.Path)
+	}
+	console.Logger.Printf("No route matches %s %s (closest routes: %s; %d route(s) with another method or query)\n",
+		req.Method, req.URL.RequestURI(), strings.Join(closest, ", "), len(d.Mismatches))
+	return c.JSON(http.StatusNotFound, d)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"gaelgirodon.fr/liege/internal/model"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func Test_diagnose(t *testing.T) {
+	routes := []*model.Route{
+		{FilePath: "items/1__GET.json", Path: "/items/1", Method: "GET"},
+		{FilePath: "items/1__GET.json", Path: "/items/1.json", Method: "GET"},
+		{FilePath: "items/index__qs.json", Path: "/items", QueryParams: []model.QueryParam{{Name: "s"}}},
+		{FilePath: "users/index.json", Path: "/users"},
+	}
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		wantClosest    string
+		wantMismatches int
+		wantHint       string
+	}{
+		{"typo", "GET", "/itemz", "/items", 0, ""},
+		{"query", "GET", "/items", "/items/1", 1, ""},
+		{"method", "POST", "/items/1", "/items", 1, ""},
+		{"slash", "GET", "/users/", "/users", 0, "try /users "},
+		{"extension", "GET", "/items/1.xml", "/items/1", 0, "try /items/1 "},
+		{"index", "GET", "/users/index.html", "/users", 0, "try /users "},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := diagnose(routes, test.method, test.path, url.Values{})
+			if len(d.Closest) == 0 || d.Closest[0].Path != test.wantClosest {
+				t.Errorf("want closest route %s, got %+v", test.wantClosest, d.Closest)
+			}
+			if len(d.Mismatches) != test.wantMismatches {
+				t.Errorf("want %d route(s) with another method or query, got %+v", test.wantMismatches, d.Mismatches)
+			}
+			if hints := strings.Join(d.Hints, "\n"); !strings.Contains(hints, test.wantHint) ||
+				len(test.wantHint) == 0 && len(hints) > 0 {
+				t.Errorf("want hint '%s', got %q", test.wantHint, d.Hints)
+			}
+		})
+	}
+}
+
+func Test_editDistance(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{{"", "", 0}, {"/items", "/items", 0}, {"/itemz", "/items", 1}, {"/item", "/items/1", 3}, {"", "/a", 2}} {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("want distance(%s, %s) = %d, got %d", test.a, test.b, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"gaelgirodon.fr/liege/internal/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+// explainRequest is the description of a request to explain.
+type explainRequest struct {
+	// Method is the request HTTP method (GET by default).
+	Method string `json:"method"`
+	// URL is the request URL (path and query string).
+	URL string `json:"url"`
+	// Headers are the request headers.
+	Headers map[string]string `json:"headers"`
+	// Body is the request body.
+	Body string `json:"body"`
+}
+
+// explanation describes how a request would be handled by the stub server.
+type explanation struct {
+	// Candidates are all routes (of the request profile, if any, and base routes)
+	// in evaluation order with their match checks.
+	Candidates []candidate `json:"candidates"`
+	// Route is the route that would serve the request (if any).
+	Route *model.Route `json:"route"`
+	// Status is the response status code that would apply (if known).
+	Status int `json:"status,omitempty"`
+	// Latency is the latency range that would apply.
+	Latency model.Latency `json:"latency"`
+	// Violations are the request violations of the OpenAPI specification (if any).
+	Violations []string `json:"violations,omitempty"`
+	// Upstream is the upstream server the request would be forwarded to (if any).
+	Upstream string `json:"upstream,omitempty"`
+}
+
+// candidate is a route evaluated against a request.
+type candidate struct {
+	// FilePath is the path to the stub file of the route (if any).
+	FilePath string `json:"file_path,omitempty"`
+	// ID is the identifier of the runtime route (if any).
+	ID string `json:"id,omitempty"`
+	// Matched indicates whether the route matches the request.
+	Matched bool `json:"matched"`
+	// Checks are the results of the route eligibility checks.
+	Checks []model.MatchCheck `json:"checks"`
+}
+
+// explain evaluates all routes against the request, without serving it.
+func (s *StubServer) explain(req *http.Request, body []byte) explanation {
+	result := explanation{Candidates: []candidate{}, Latency: model.Latency{Min: 0, Max: 0}}
+	config := s.config()
+	if s.spec != nil {
+		result.Violations = s.spec.ValidateRequest(req, body)
+		if len(result.Violations) > 0 && s.Config.SpecMode != model.ReportViolations {
+			result.Status = http.StatusBadRequest
+		}
+	}
+	for _, route := range s.getProfileRoutes(req.Header.Get(profileHeader)) {
+		c := candidate{FilePath: route.FilePath, ID: route.ID, Matched: true,
+			Checks: route.Checks(req.Method, req.URL.Path, req.URL.Query())}
+		for _, check := range c.Checks {
+			c.Matched = c.Matched && check.Passed
+		}
+		result.Candidates = append(result.Candidates, c)
+		if c.Matched && result.Route == nil && result.Status == 0 {
+			result.Route = route
+			result.Status = route.Code
+			result.Latency = route.Latency.Resolve(config.Latency)
+		}
+	}
+	if result.Status == 0 {
+		if upstream, found := config.Upstream(req.URL.Path); found {
+			result.Upstream = upstream.URL
+		} else {
+			result.Status = http.StatusNotFound
+		}
+	}
+	return result
+}
+
+// explainHandler explains why a described request would or wouldn't match each route.
+func (s *StubServer) explainHandler(c echo.Context) error {
+	r := new(explainRequest)
+	if err := c.Bind(r); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid body")
+	}
+	if len(r.Method) == 0 {
+		r.Method = http.MethodGet
+	}
+	if !strings.HasPrefix(r.URL, "/") {
+		return echo.NewHTTPError(http.StatusBadRequest, "url must start with /")
+	}
+	req, err := http.NewRequest(strings.ToUpper(r.Method), r.URL, strings.NewReader(r.Body))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+	for name, value := range r.Headers {
+		req.Header.Set(name, value)
+	}
+	return c.JSON(http.StatusOK, s.explain(req, []byte(r.Body)))
+}
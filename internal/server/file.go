@@ -1,9 +1,11 @@
 package server
 
 import (
+	"encoding/json"
 	"errors"
+	"gaelgirodon.fr/liege/internal/console"
 	"gaelgirodon.fr/liege/internal/model"
-	"github.com/labstack/echo/v4"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -17,6 +19,18 @@ const (
 	optsPrefix = "__"
 	// optsSeparator is the separator between options in a file name.
 	optsSeparator = "_"
+	// sidecarSuffix is the suffix of a sidecar file name
+	// (stub file name + suffix) customizing the stub response.
+	sidecarSuffix = ".liege.json"
+	// tombstoneSuffix is the suffix of a tombstone file name (stub file name + suffix)
+	// deleting the routes of the stub file from lower layers.
+	tombstoneSuffix = ".liege.delete"
+	// profilesDirName is the name of the directory in the root directory
+	// containing a subdirectory of stub files per profile.
+	profilesDirName = "_profiles"
+	// contentTypesFileName is the name of the file in the root directory
+	// mapping file extensions to content types.
+	contentTypesFileName = "liege.mime.json"
 )
 
 var (
@@ -26,10 +40,106 @@ var (
 	queryOptPattern = regexp.MustCompile("^q([a-z0-9-]+)(?:=([a-z0-9-]+))?$")
 	// codeOptPattern is the pattern to match the custom HTTP response status code option.
 	codeOptPattern = regexp.MustCompile("^([1-5][0-9]{2})$")
+	// typeOptPattern is the pattern to match the content type option.
+	typeOptPattern = regexp.MustCompile("^t([a-z0-9.+-]+)$")
 )
 
+// defaultContentTypes maps file extensions to content types for common
+// stub file types missing from (or inconsistent in) the MIME registry.
+var defaultContentTypes = contentTypes{
+	".csv":    "text/csv; charset=utf-8",
+	".tsv":    "text/tab-separated-values; charset=utf-8",
+	".txt":    "text/plain; charset=utf-8",
+	".md":     "text/markdown; charset=utf-8",
+	".yaml":   "application/yaml",
+	".yml":    "application/yaml",
+	".toml":   "application/toml",
+	".ndjson": "application/x-ndjson",
+}
+
+// contentTypes maps file extensions (e.g. ".json") to content types.
+type contentTypes map[string]string
+
+// loadContentTypes loads the custom content types mapping file
+// from the root directory (if any).
+func loadContentTypes(root string) (contentTypes, error) {
+	data, err := os.ReadFile(filepath.Join(root, contentTypesFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return contentTypes{}, nil
+	} else if err != nil {
+		return nil, errors.New("unable to read " + contentTypesFileName)
+	}
+	types := contentTypes{}
+	if err = json.Unmarshal(data, &types); err != nil {
+		return nil, errors.New("invalid " + contentTypesFileName + " file: " + err.Error())
+	}
+	for ext, contentType := range types {
+		if !strings.HasPrefix(ext, ".") {
+			delete(types, ext)
+			types["."+ext] = contentType
+		}
+	}
+	return types, nil
+}
+
+// byExtension returns the content type associated with the given file extension
+// using the custom mapping, the default mapping and the MIME registry.
+func (types contentTypes) byExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if contentType, ok := types[ext]; ok {
+		return contentType
+	} else if contentType, ok = defaultContentTypes[ext]; ok {
+		return contentType
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// sidecar is the content of a sidecar file customizing
+// the stub route beyond what the file name can express.
+type sidecar struct {
+	// ContentType is the exact response content type.
+	ContentType string `json:"content_type,omitempty"`
+	// QueryParams are additional required query parameters.
+	QueryParams []model.QueryParam `json:"query_params,omitempty"`
+	// Headers are additional response headers.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// isEmpty indicates whether the sidecar doesn't customize anything.
+func (meta sidecar) isEmpty() bool {
+	return len(meta.ContentType) == 0 && len(meta.QueryParams) == 0 && len(meta.Headers) == 0
+}
+
+// readSidecar reads the sidecar file of the given stub file (if any).
+func readSidecar(path string) (sidecar, error) {
+	var meta sidecar
+	data, err := os.ReadFile(path + sidecarSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return meta, nil
+	} else if err != nil {
+		return meta, errors.New("unable to read sidecar file")
+	}
+	if err = json.Unmarshal(data, &meta); err != nil {
+		return meta, errors.New("invalid sidecar file: " + err.Error())
+	}
+	return meta, nil
+}
+
+// isReservedFile indicates whether the file at the given path (relative to the root
+// directory) has a name reserved for server or content types configuration.
+func isReservedFile(relPath string) bool {
+	return filepath.ToSlash(relPath) == contentTypesFileName || filepath.ToSlash(relPath) == console.ConfigFileName
+}
+
+// isMetaFile indicates whether the file at the given path (relative to the root
+// directory) configures the server or stubs instead of being a stub file.
+func isMetaFile(relPath string) bool {
+	return isReservedFile(relPath) ||
+		strings.HasSuffix(relPath, sidecarSuffix) || strings.HasSuffix(relPath, tombstoneSuffix)
+}
+
 // parseFileName parses the file name and extract the name, extension and options.
-func parseFileName(filename string) (name, ext string, route model.Route, err error) {
+func parseFileName(filename string, types contentTypes) (name, ext string, route model.Route, err error) {
 	ext = filepath.Ext(filename)
 	name = strings.TrimSuffix(filename, ext)
 	route = model.NewRoute()
@@ -51,6 +161,17 @@ func parseFileName(filename string) (name, ext string, route model.Route, err er
 			route.Code, _ = strconv.Atoi(match[1])
 		} else if latency, parsingErr := model.ParseLatency(opt, "l"); parsingErr == nil {
 			route.Latency = latency
+		} else if rateLimit, parsingErr := model.ParseRateLimit(opt, "rl"); parsingErr == nil {
+			route.RateLimit = rateLimit
+		} else if match := typeOptPattern.FindStringSubmatch(opt); len(match) == 2 {
+			if route.ContentType = types.byExtension("." + match[1]); len(route.ContentType) > 0 {
+				continue
+			} else if strings.Contains(match[1], "+") { // Structured syntax suffix, e.g. problem+json
+				route.ContentType = "application/" + match[1]
+				continue
+			}
+			err = errors.New("unknown content type '" + match[1] + "'")
+			return
 		} else {
 			err = errors.New("unknown or invalid option '" + opt + "'")
 			return
@@ -59,26 +180,61 @@ func parseFileName(filename string) (name, ext string, route model.Route, err er
 	return
 }
 
-// readFile reads a file and returns the contents and the content type (MIME type).
-func readFile(path string) ([]byte, string, error) {
+// formatFileName builds a stub file name from the name, the extension and the route options
+// (inverse of parseFileName) and returns the query parameters the file name cannot express.
+func formatFileName(name, ext string, route model.Route) (filename string, extraParams []model.QueryParam) {
+	var opts []string
+	if len(route.Method) > 0 {
+		opts = append(opts, route.Method)
+	}
+	for _, qp := range route.QueryParams {
+		opt := "q" + qp.Name
+		if len(qp.Value) > 0 {
+			opt += "=" + qp.Value
+		}
+		if queryOptPattern.MatchString(opt) {
+			opts = append(opts, opt)
+		} else {
+			extraParams = append(extraParams, qp)
+		}
+	}
+	if route.Code != http.StatusOK {
+		opts = append(opts, strconv.Itoa(route.Code))
+	}
+	if !route.Latency.IsDisabledOrUndefined() {
+		opts = append(opts, "l"+strconv.Itoa(route.Latency.Min))
+		if route.Latency.Max != route.Latency.Min {
+			opts[len(opts)-1] += "-" + strconv.Itoa(route.Latency.Max)
+		}
+	}
+	filename = name
+	if len(opts) > 0 {
+		filename += optsPrefix + strings.Join(opts, optsSeparator)
+	}
+	return filename + ext, extraParams
+}
+
+// readFile reads a file and returns its contents and the content type (MIME type)
+// resolved from the forced content type (if any), the extension or the contents.
+func readFile(path string, contentType string, types contentTypes) ([]byte, string, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, "", errors.New("unable to read file")
 	}
-	contentType := ""
+	detected := ""
 	if len(content) > 0 {
-		contentType = http.DetectContentType(content)
-	}
-	if strings.Contains(contentType, "text/plain") {
-		switch ext := filepath.Ext(path); ext {
-		case ".json":
-			contentType = strings.Replace(contentType, "text/plain", echo.MIMEApplicationJSON, 1)
-		case ".xml":
-			contentType = strings.Replace(contentType, "text/plain", echo.MIMETextXML, 1)
-		case ".html":
-			contentType = strings.Replace(contentType, "text/plain", echo.MIMETextHTML, 1)
-		case ".js":
-			contentType = strings.Replace(contentType, "text/plain", echo.MIMEApplicationJavaScript, 1)
+		detected = http.DetectContentType(content)
+	}
+	if len(contentType) == 0 {
+		contentType = types.byExtension(filepath.Ext(path))
+	}
+	if len(contentType) == 0 {
+		return content, detected, nil
+	}
+	// Add the detected charset to text content types
+	if _, detectedParams, err := mime.ParseMediaType(detected); err == nil && len(detectedParams["charset"]) > 0 {
+		if _, params, err := mime.ParseMediaType(contentType); err == nil && len(params["charset"]) == 0 {
+			contentType += "; charset=" + detectedParams["charset"]
 		}
 	}
 	return content, contentType, nil
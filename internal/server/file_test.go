@@ -2,6 +2,8 @@ package server
 
 import (
 	"gaelgirodon.fr/liege/internal/model"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -45,12 +47,23 @@ func Test_parseFileName(t *testing.T) {
 			model.Route{Code: 200, Latency: model.Latency{Min: 10, Max: 30}}, false},
 		{"latency/err", "test__l999999", "test", "",
 			model.Route{}, true},
-		{"all", "test__POST_qn_403_l50.txt", "test", ".txt",
-			model.Route{Method: "POST", QueryParams: []model.QueryParam{{Name: "n"}}, Code: 403, Latency: model.Latency{Min: 50, Max: 50}}, false},
+		{"ratelimit", "test__rl10s", "test", "",
+			model.Route{Code: 200, Latency: model.Latency{Min: -1, Max: -1}, RateLimit: model.RateLimit{Limit: 10, Period: 1}}, false},
+		{"ratelimit/err", "test__rl10x", "test", "",
+			model.Route{}, true},
+		{"type/ext", "test__tcsv", "test", "",
+			model.Route{Code: 200, ContentType: "text/csv; charset=utf-8", Latency: model.Latency{Min: -1, Max: -1}}, false},
+		{"type/suffix", "test__tproblem+json.json", "test", ".json",
+			model.Route{Code: 200, ContentType: "application/problem+json", Latency: model.Latency{Min: -1, Max: -1}}, false},
+		{"type/err", "test__tunknown", "test", "",
+			model.Route{}, true},
+		{"all", "test__POST_qn_403_l50_rl2m.txt", "test", ".txt",
+			model.Route{Method: "POST", QueryParams: []model.QueryParam{{Name: "n"}}, Code: 403, Latency: model.Latency{Min: 50, Max: 50},
+				RateLimit: model.RateLimit{Limit: 2, Period: 60}}, false},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			name, ext, route, err := parseFileName(test.filename)
+			name, ext, route, err := parseFileName(test.filename, nil)
 			if test.wantErr != (err != nil) {
 				t.Errorf("want error = %v, got %v (%v)", test.wantErr, err != nil, err)
 			}
@@ -78,6 +91,76 @@ func Test_parseFileName(t *testing.T) {
 			if route.Latency != test.wantRoute.Latency {
 				t.Errorf("want latency = %v, got %v", test.wantRoute.Latency, route.Latency)
 			}
+			if route.ContentType != test.wantRoute.ContentType {
+				t.Errorf("want content type = %v, got %v", test.wantRoute.ContentType, route.ContentType)
+			}
+			if route.RateLimit != test.wantRoute.RateLimit {
+				t.Errorf("want rate limit = %v, got %v", test.wantRoute.RateLimit, route.RateLimit)
+			}
+		})
+	}
+}
+
+func Test_readFile(t *testing.T) {
+	dir := t.TempDir()
+	types := contentTypes{".hal": "application/hal+json"}
+	tests := []struct {
+		name            string
+		filename        string
+		content         string
+		contentType     string
+		wantContentType string
+	}{
+		{"json", "test.json", `{"id":1}`, "", "application/json; charset=utf-8"},
+		{"css", "test.css", "a{}", "", "text/css; charset=utf-8"},
+		{"svg", "test.svg", "<svg></svg>", "", "image/svg+xml; charset=utf-8"},
+		{"yaml", "test.yaml", "a: 1", "", "application/yaml; charset=utf-8"},
+		{"csv", "test.csv", "a,b", "", "text/csv; charset=utf-8"},
+		{"txt", "test.txt", "a", "", "text/plain; charset=utf-8"},
+		{"md", "test.md", "# A", "", "text/markdown; charset=utf-8"},
+		{"custom", "test.hal", `{"_links":{}}`, "", "application/hal+json; charset=utf-8"},
+		{"forced", "test.json", `{}`, "application/problem+json", "application/problem+json; charset=utf-8"},
+		{"detected", "test", "<html></html>", "", "text/html; charset=utf-8"},
+		{"empty", "test", "", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.filename)
+			_ = os.WriteFile(path, []byte(test.content), 0666)
+			content, contentType, err := readFile(path, test.contentType, types)
+			if err != nil {
+				t.Fatalf("want no error, got %v", err)
+			}
+			if string(content) != test.content {
+				t.Errorf("want content = %q, got %q", test.content, content)
+			}
+			if contentType != test.wantContentType {
+				t.Errorf("want content type = %q, got %q", test.wantContentType, contentType)
+			}
+		})
+	}
+}
+
+func Test_isMetaFile(t *testing.T) {
+	tests := []struct {
+		relPath      string
+		wantReserved bool
+		wantMeta     bool
+	}{
+		{"liege.json", true, true},
+		{"liege.mime.json", true, true},
+		{filepath.Join("api", "liege.json"), false, false},
+		{"error__500.json.liege.json", false, true},
+		{"items.json", false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.relPath, func(t *testing.T) {
+			if got := isReservedFile(test.relPath); got != test.wantReserved {
+				t.Errorf("want reserved = %v, got %v", test.wantReserved, got)
+			}
+			if got := isMetaFile(test.relPath); got != test.wantMeta {
+				t.Errorf("want meta = %v, got %v", test.wantMeta, got)
+			}
 		})
 	}
 }
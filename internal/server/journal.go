@@ -0,0 +1,122 @@
+package server
+
+import (
+	"errors"
+	"gaelgirodon.fr/liege/internal/model"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxJournalBodySize is the maximum size of a request body kept in the journal (in bytes).
+const maxJournalBodySize = 64 * 1024
+
+// journalEntry is a request received by the stub server.
+type journalEntry struct {
+	// Timestamp is the time at which the request was received.
+	Timestamp time.Time `json:"timestamp"`
+	model.Request
+	// BodyTruncated indicates whether the request body has been truncated to maxJournalBodySize.
+	BodyTruncated bool `json:"body_truncated,omitempty"`
+	// FilePath is the path to the stub file of the matched route (if any).
+	FilePath string `json:"file_path,omitempty"`
+	// RouteID is the identifier of the matched runtime route (if any).
+	RouteID string `json:"route_id,omitempty"`
+	// Status is the response status code.
+	Status int `json:"status"`
+	// Latency is the applied latency in ms.
+	Latency int64 `json:"latency"`
+}
+
+// journalFilter selects journal entries.
+type journalFilter struct {
+	// path is the URL path pattern (path.Match syntax) entries must match.
+	path string
+	// method is the HTTP method entries must have.
+	method string
+	// status is the status code entries must have.
+	status int
+	// since is the time entries must be received at or after.
+	since time.Time
+	// until is the time entries must be received at or before.
+	until time.Time
+}
+
+// parseJournalFilter parses a journal filter from query parameters
+// (path, method, status, since and until as RFC 3339 times).
+func parseJournalFilter(query url.Values) (f journalFilter, err error) {
+	f.path = query.Get("path")
+	if _, err = path.Match(f.path, ""); err != nil {
+		return f, errors.New("invalid path pattern")
+	}
+	f.method = strings.ToUpper(query.Get("method"))
+	if value := query.Get("status"); len(value) > 0 {
+		if f.status, err = strconv.Atoi(value); err != nil {
+			return f, errors.New("invalid status code")
+		}
+	}
+	for name, t := range map[string]*time.Time{"since": &f.since, "until": &f.until} {
+		if value := query.Get(name); len(value) > 0 {
+			if *t, err = time.Parse(time.RFC3339, value); err != nil {
+				return f, errors.New("invalid " + name + " time")
+			}
+		}
+	}
+	return f, nil
+}
+
+// Match indicates whether the entry is selected by the filter.
+func (f *journalFilter) Match(e *journalEntry) bool {
+	if matched, _ := path.Match(f.path, e.Path); len(f.path) > 0 && !matched {
+		return false
+	}
+	return (len(f.method) == 0 || e.Method == f.method) &&
+		(f.status == 0 || e.Status == f.status) &&
+		(f.since.IsZero() || !e.Timestamp.Before(f.since)) &&
+		(f.until.IsZero() || !e.Timestamp.After(f.until))
+}
+
+// journal keeps the last requests received by the stub server.
+type journal struct {
+	// mu guards entries.
+	mu sync.Mutex
+	// entries are the received requests (oldest first).
+	entries []journalEntry
+}
+
+// Add adds an entry to the journal, dropping the oldest entries
+// to keep at most size entries.
+func (j *journal) Add(entry journalEntry, size int) {
+	if size <= 0 {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, entry)
+	if excess := len(j.entries) - size; excess > 0 {
+		j.entries = append(j.entries[:0], j.entries[excess:]...)
+	}
+}
+
+// Entries returns the entries selected by the filter (oldest first).
+func (j *journal) Entries(f journalFilter) []journalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries := make([]journalEntry, 0, len(j.entries))
+	for i := range j.entries {
+		if f.Match(&j.entries[i]) {
+			entries = append(entries, j.entries[i])
+		}
+	}
+	return entries
+}
+
+// Clear removes all entries from the journal.
+func (j *journal) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = nil
+}
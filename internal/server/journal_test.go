@@ -0,0 +1,100 @@
+package server
+
+import (
+	"gaelgirodon.fr/liege/internal/model"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_journal(t *testing.T) {
+	var j journal
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		j.Add(journalEntry{Timestamp: now.Add(time.Duration(i) * time.Minute),
+			Request: model.Request{Method: "GET", Path: "/items/" + string(rune('0'+i))},
+			Status:  200 + i%2*204}, 3)
+	}
+	entries := j.Entries(journalFilter{})
+	if len(entries) != 3 || entries[0].Path != "/items/2" || entries[2].Path != "/items/4" {
+		t.Fatalf("want the 3 last entries, got %+v", entries)
+	}
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"path", "path=/items/3", 1},
+		{"path/pattern", "path=/items/*", 3},
+		{"method", "method=post", 0},
+		{"status", "status=404", 1},
+		{"since", "since=" + url.QueryEscape(now.Add(3*time.Minute).Format(time.RFC3339Nano)), 2},
+		{"until", "until=" + url.QueryEscape(now.Add(3*time.Minute).Format(time.RFC3339Nano)), 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(test.query)
+			filter, err := parseJournalFilter(query)
+			if err != nil {
+				t.Fatalf("want no error, got %v", err)
+			}
+			if got := j.Entries(filter); len(got) != test.want {
+				t.Errorf("want %d entries, got %d", test.want, len(got))
+			}
+		})
+	}
+	for _, query := range []string{"status=abc", "since=yesterday", "path=["} {
+		q, _ := url.ParseQuery(query)
+		if _, err := parseJournalFilter(q); err == nil {
+			t.Errorf("want error parsing %s", query)
+		}
+	}
+	j.Clear()
+	if entries = j.Entries(journalFilter{}); len(entries) != 0 {
+		t.Errorf("want no entries after clear, got %d", len(entries))
+	}
+	j.Add(journalEntry{}, -1)
+	if entries = j.Entries(journalFilter{}); len(entries) != 0 {
+		t.Errorf("want journal to be disabled, got %d entries", len(entries))
+	}
+}
+
+func Test_readBodyPrefix(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "/items", strings.NewReader("0123456789"))
+	if prefix, truncated := readBodyPrefix(req, 4); string(prefix) != "0123" || !truncated {
+		t.Errorf("want truncated prefix 0123, got %q (%v)", prefix, truncated)
+	}
+	if body, _ := io.ReadAll(req.Body); string(body) != "0123456789" {
+		t.Errorf("want the full body to be read again, got %q", body)
+	}
+	req, _ = http.NewRequest(http.MethodPost, "/items", strings.NewReader("0123"))
+	if prefix, truncated := readBodyPrefix(req, 4); string(prefix) != "0123" || truncated {
+		t.Errorf("want full body 0123, got %q (%v)", prefix, truncated)
+	}
+}
+
+func Test_journal_verify(t *testing.T) {
+	var j journal
+	for _, entry := range []journalEntry{
+		{Request: model.Request{Method: "POST", Path: "/payments", Body: `{"amount":42}`}},
+		{Request: model.Request{Method: "POST", Path: "/payments", Body: `{"amount":`}, BodyTruncated: true},
+		{Request: model.Request{Method: "POST", Path: "/refunds", Body: `{"amount":`}, BodyTruncated: true},
+	} {
+		j.Add(entry, 10)
+	}
+	one, two := 1, 2
+	for _, count := range []*int{&one, &two} {
+		v := &model.Verification{Request: model.RequestPattern{Method: "POST", Path: "/payments",
+			Body: &model.BodyMatcher{Contains: "42"}}, Count: count}
+		if got := j.verify(v); got.Count != 1 || len(got.Indeterminate) != 1 || len(got.Closest) != 1 || got.Matched {
+			t.Errorf("want 1 matching, 1 indeterminate and 1 closest request (not matched), got %+v", got)
+		}
+	}
+	v := &model.Verification{Request: model.RequestPattern{Method: "POST", Path: "/payments"}, Count: &two}
+	if got := j.verify(v); got.Count != 2 || len(got.Indeterminate) != 0 || !got.Matched {
+		t.Errorf("want truncated requests to match a pattern without body, got %+v", got)
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"gaelgirodon.fr/liege/internal/model"
+	"io"
+	"mime"
+	"sort"
+	"strings"
+)
+
+const (
+	// LintError is the severity of issues preventing stubs from being served.
+	LintError = "error"
+	// LintWarning is the severity of issues that may serve unexpected stubs.
+	LintWarning = "warning"
+)
+
+// LintIssue is an issue found in a stub tree.
+type LintIssue struct {
+	// Severity is the issue severity (error or warning).
+	Severity string `json:"severity"`
+	// FilePath is the path to the stub file (empty if unknown).
+	FilePath string `json:"file_path,omitempty"`
+	// Message describes the issue.
+	Message string `json:"message"`
+}
+
+// LoadRoutes loads stub response files from the given root directory and builds
+// server routes, including profile routes, with errors of files that cannot be loaded.
+func LoadRoutes(root string) ([]*model.Route, []error, error) {
+	return buildConfigRoutes(&model.Config{Root: root})
+}
+
+// Lint loads stub response files from the given root directory and reports issues:
+// files that cannot be loaded (e.g. unparseable options), invalid JSON or XML bodies,
+// unreachable routes and conflicting aliases.
+func Lint(root string) ([]LintIssue, error) {
+	routes, loadErrs, err := LoadRoutes(root)
+	if err != nil {
+		return nil, err
+	}
+	var issues []LintIssue
+	for _, loadErr := range loadErrs {
+		issues = append(issues, LintIssue{Severity: LintError, Message: loadErr.Error()})
+	}
+	issues = append(issues, lintBodies(routes)...)
+	issues = append(issues, lintShadowing(routes)...)
+	return issues, nil
+}
+
+// lintBodies reports stub files with a JSON or XML content type and an invalid body.
+func lintBodies(routes []*model.Route) (issues []LintIssue) {
+	checked := make(map[string]bool)
+	for _, route := range routes {
+		if checked[route.FilePath] || len(route.Content) == 0 {
+			continue
+		}
+		checked[route.FilePath] = true
+		mediaType, _, _ := mime.ParseMediaType(route.ContentType)
+		var err error
+		switch {
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			err = json.Unmarshal(route.Content, new(any))
+		case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+			err = validateXML(route.Content)
+		}
+		if err != nil {
+			issues = append(issues, LintIssue{Severity: LintError, FilePath: route.FilePath,
+				Message: "invalid " + mediaType + " body: " + err.Error()})
+		}
+	}
+	return issues
+}
+
+// validateXML checks that the given content is a well-formed XML document.
+func validateXML(content []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		if _, err := decoder.Token(); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// lintShadowing reports routes matching the same requests (method, path and query
+// parameters) as an earlier route of the same profile, that can therefore never
+// be served: stub files with all their routes shadowed are unreachable, and
+// others have conflicting aliases.
+func lintShadowing(routes []*model.Route) (issues []LintIssue) {
+	first := make(map[string]*model.Route)
+	total := make(map[string]int)
+	shadowed := make(map[string][]*model.Route)
+	var files []string
+	for _, route := range routes {
+		if total[route.FilePath] == 0 {
+			files = append(files, route.FilePath)
+		}
+		total[route.FilePath]++
+		key := route.Profile + "|" + route.Signature()
+		if earlier, exists := first[key]; exists && earlier.FilePath != route.FilePath {
+			shadowed[route.FilePath] = append(shadowed[route.FilePath], earlier)
+		} else if !exists {
+			first[key] = route
+		}
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if len(shadowed[file]) == 0 {
+			continue
+		} else if len(shadowed[file]) == total[file] {
+			issues = append(issues, LintIssue{Severity: LintError, FilePath: file,
+				Message: "unreachable stub file, all its routes are shadowed by " + shadowingFiles(shadowed[file])})
+			continue
+		}
+		for _, earlier := range shadowed[file] {
+			issues = append(issues, LintIssue{Severity: LintWarning, FilePath: file,
+				Message: "conflicting alias " + earlier.Signature() + ", served by " + earlier.FilePath})
+		}
+	}
+	return issues
+}
+
+// shadowingFiles returns the distinct file paths of the given routes as a string.
+func shadowingFiles(routes []*model.Route) string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, route := range routes {
+		if !seen[route.FilePath] {
+			seen[route.FilePath] = true
+			files = append(files, route.FilePath)
+		}
+	}
+	return strings.Join(files, ", ")
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"items.json":         "[]",
+		"items__200.json":    "[]",                // Unreachable: same routes as items.json
+		"items/index.xml":    "<items></items>",   // Conflicting alias: /items
+		"users__GTE.json":    "[]",                // Unparseable option
+		"broken.json":        `{"id":`,            // Invalid JSON
+		"invalid.xml":        "<broken>",          // Invalid XML
+		"health__GET.json":   `{"status":"up"}`,   // Valid
+		"health__POST.json":  `{"status":"up"}`,   // Valid (other method)
+		"health__qfull.json": `{"status":"full"}`, // Valid (query parameter)
+	}
+	for name, content := range files {
+		_ = os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755)
+		_ = os.WriteFile(filepath.Join(root, name), []byte(content), 0644)
+	}
+	issues, err := Lint(root)
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	got := make(map[string]string)
+	for _, issue := range issues {
+		got[issue.FilePath] += issue.Severity
+	}
+	want := map[string]string{"": LintError, "items__200.json": LintError, "items/index.xml": LintWarning,
+		"broken.json": LintError, "invalid.xml": LintError}
+	if len(got) != len(want) {
+		t.Errorf("want issues %v, got %+v", want, issues)
+	}
+	for file, severity := range want {
+		if got[file] != severity {
+			t.Errorf("want %s issue for %q, got %q (%+v)", severity, file, got[file], issues)
+		}
+	}
+}
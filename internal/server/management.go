@@ -0,0 +1,119 @@
+package server
+
+import (
+	"crypto/subtle"
+	"gaelgirodon.fr/liege/internal/console"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// registerManagementRoutes registers management endpoints on the given group.
+func (s *StubServer) registerManagementRoutes(g *echo.Group) {
+	g.GET("/config", s.getConfigHandler)
+	g.PUT("/config", s.updateConfigHandler)
+	g.POST("/refresh", s.refreshHandler)
+	g.GET("/errors", s.loadErrorsHandler)
+	g.GET("/routes", s.routesHandler)
+	g.GET("/routes/content", s.routeContentHandler)
+	g.POST("/routes", s.createRouteHandler)
+	g.PUT("/routes/:id", s.updateRouteHandler)
+	g.DELETE("/routes/:id", s.deleteRouteHandler)
+	g.DELETE("/routes", s.deleteRoutesHandler)
+	g.GET("/openapi.json", s.openAPIHandler)
+	g.GET("/ratelimits", s.getRateLimitsHandler)
+	g.DELETE("/ratelimits", s.resetRateLimitsHandler)
+	g.Any("/echo", s.echoHandler)
+	g.GET("/requests", s.getRequestsHandler)
+	g.DELETE("/requests", s.clearRequestsHandler)
+	g.POST("/verify", s.verifyHandler)
+	g.POST("/explain", s.explainHandler)
+	g.GET("/metrics", s.metricsHandler)
+}
+
+// registerProbeRoutes registers health and readiness endpoints on the given group
+// (without authentication to be usable by health checks).
+func (s *StubServer) registerProbeRoutes(g *echo.Group) {
+	g.GET("/health", s.healthHandler)
+	g.GET("/ready", s.readyHandler)
+}
+
+// healthHandler reports that the server is up.
+func (s *StubServer) healthHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": "up"})
+}
+
+// readyHandler reports whether the server is ready to serve stubs.
+func (s *StubServer) readyHandler(c echo.Context) error {
+	if ready, reason := s.isReady(); !ready {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"status": "not ready", "reason": reason})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// managementMiddlewares returns the middlewares protecting management endpoints:
+// loopback restriction (on the stubs listener) and authentication (if enabled).
+func (s *StubServer) managementMiddlewares(separate bool) []echo.MiddlewareFunc {
+	var mws []echo.MiddlewareFunc
+	if s.Config.MgmtLoopback && !separate {
+		mws = append(mws, loopbackOnly)
+	}
+	if s.Config.HasManagementAuth() {
+		mws = append(mws, s.authenticate)
+	}
+	return mws
+}
+
+// uiMiddlewares returns the middlewares protecting the dashboard and probes on
+// the stubs listener (loopback restriction if enabled).
+func (s *StubServer) uiMiddlewares() []echo.MiddlewareFunc {
+	if s.Config.MgmtLoopback {
+		return []echo.MiddlewareFunc{loopbackOnly}
+	}
+	return nil
+}
+
+// loopbackOnly rejects requests not sent from the loopback interface.
+func loopbackOnly(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		host, _, err := net.SplitHostPort(c.Request().RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			return echo.NewHTTPError(http.StatusForbidden, "management endpoints are restricted to loopback")
+		}
+		return next(c)
+	}
+}
+
+// authenticate rejects requests without the management token or basic auth credentials.
+func (s *StubServer) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		auth := c.Request().Header.Get(echo.HeaderAuthorization)
+		if token, found := strings.CutPrefix(auth, "Bearer "); found && len(s.Config.MgmtToken) > 0 &&
+			subtle.ConstantTimeCompare([]byte(token), []byte(s.Config.MgmtToken)) == 1 {
+			return next(c)
+		}
+		if user, password, ok := c.Request().BasicAuth(); ok && len(s.Config.MgmtAuth) > 0 &&
+			subtle.ConstantTimeCompare([]byte(user+":"+password), []byte(s.Config.MgmtAuth)) == 1 {
+			return next(c)
+		}
+		if len(s.Config.MgmtAuth) > 0 {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="`+console.AppName+`"`)
+		} else {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="`+console.AppName+`"`)
+		}
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid management credentials")
+	}
+}
+
+// newEcho creates a new HTTP server with common middlewares.
+func newEcho() *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.Use(middleware.Logger())
+	e.Use(middleware.Recover())
+	e.Pre(middleware.RemoveTrailingSlash())
+	return e
+}
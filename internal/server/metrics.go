@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsContentType is the content type of the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// durationBuckets are the upper bounds (in seconds) of request duration histogram buckets.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// requestLabels are the labels of request metrics.
+type requestLabels struct {
+	// route is the stub file path (or runtime route identifier) of the matched route.
+	route string
+	// method is the request HTTP method.
+	method string
+	// status is the response status code.
+	status int
+}
+
+// histogram counts observed values in cumulative buckets.
+type histogram struct {
+	// counts are the number of observations per bucket (same indexes as durationBuckets).
+	counts []uint64
+	// count is the total number of observations.
+	count uint64
+	// sum is the sum of observed values.
+	sum float64
+}
+
+// metrics collects stub server metrics.
+type metrics struct {
+	// mu guards all fields.
+	mu sync.Mutex
+	// requests are the request counts per labels.
+	requests map[requestLabels]uint64
+	// durations are the request duration histograms per labels.
+	durations map[requestLabels]*histogram
+	// unmatched is the number of requests no route matched.
+	unmatched uint64
+	// loadErrors is the number of stub files that could not be loaded during the last refresh.
+	loadErrors int
+	// lastRefresh is the time of the last successful refresh.
+	lastRefresh time.Time
+	// lastRefreshDuration is the duration of the last successful refresh.
+	lastRefreshDuration time.Duration
+}
+
+// ObserveRequest records a served request.
+func (m *metrics) ObserveRequest(route string, method string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.requests == nil {
+		m.requests = make(map[requestLabels]uint64)
+		m.durations = make(map[requestLabels]*histogram)
+	}
+	labels := requestLabels{route, method, status}
+	m.requests[labels]++
+	h, exists := m.durations[labels]
+	if !exists {
+		h = &histogram{counts: make([]uint64, len(durationBuckets))}
+		m.durations[labels] = h
+	}
+	seconds := duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+	if len(route) == 0 {
+		m.unmatched++
+	}
+}
+
+// ObserveRefresh records a successful refresh of routes.
+func (m *metrics) ObserveRefresh(start time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastRefresh = start
+	m.lastRefreshDuration = time.Since(start)
+}
+
+// ObserveLoadErrors records the number of stub files that could not be loaded
+// during a refresh (even if it failed in strict mode).
+func (m *metrics) ObserveLoadErrors(loadErrors int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loadErrors = loadErrors
+}
+
+// WriteTo writes metrics in the Prometheus text exposition format.
+func (m *metrics) WriteTo(w io.Writer, routes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	labels := make([]requestLabels, 0, len(m.requests))
+	for l := range m.requests {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return fmt.Sprint(labels[i]) < fmt.Sprint(labels[j])
+	})
+	writeHeader(w, "liege_requests_total", "counter", "Number of requests received on stub routes.")
+	for _, l := range labels {
+		_, _ = fmt.Fprintf(w, "liege_requests_total{%s} %d\n", l.format(), m.requests[l])
+	}
+	writeHeader(w, "liege_request_duration_seconds", "histogram", "Duration of requests on stub routes.")
+	for _, l := range labels {
+		h := m.durations[l]
+		for i, bound := range durationBuckets {
+			_, _ = fmt.Fprintf(w, "liege_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				l.format(), strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		_, _ = fmt.Fprintf(w, "liege_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", l.format(), h.count)
+		_, _ = fmt.Fprintf(w, "liege_request_duration_seconds_sum{%s} %g\n", l.format(), h.sum)
+		_, _ = fmt.Fprintf(w, "liege_request_duration_seconds_count{%s} %d\n", l.format(), h.count)
+	}
+	writeHeader(w, "liege_unmatched_requests_total", "counter", "Number of requests no route matched.")
+	_, _ = fmt.Fprintf(w, "liege_unmatched_requests_total %d\n", m.unmatched)
+	writeHeader(w, "liege_routes", "gauge", "Number of loaded routes.")
+	_, _ = fmt.Fprintf(w, "liege_routes %d\n", routes)
+	writeHeader(w, "liege_load_errors", "gauge", "Number of stub files that could not be loaded during the last refresh.")
+	_, _ = fmt.Fprintf(w, "liege_load_errors %d\n", m.loadErrors)
+	writeHeader(w, "liege_last_refresh_timestamp_seconds", "gauge", "Time of the last successful refresh.")
+	_, _ = fmt.Fprintf(w, "liege_last_refresh_timestamp_seconds %g\n", float64(m.lastRefresh.UnixMilli())/1000)
+	writeHeader(w, "liege_last_refresh_duration_seconds", "gauge", "Duration of the last successful refresh.")
+	_, _ = fmt.Fprintf(w, "liege_last_refresh_duration_seconds %g\n", m.lastRefreshDuration.Seconds())
+}
+
+// writeHeader writes the help and type lines of a metric.
+func writeHeader(w io.Writer, name string, kind string, help string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// labelEscaper escapes label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// format formats the labels.
+func (l requestLabels) format() string {
+	return fmt.Sprintf(`route="%s",method="%s",status="%d"`,
+		labelEscaper.Replace(l.route), labelEscaper.Replace(l.method), l.status)
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_metrics(t *testing.T) {
+	var m metrics
+	m.ObserveRefresh(time.Unix(1700000000, 0))
+	m.ObserveLoadErrors(2)
+	m.ObserveRequest("items/1.json", "GET", 200, 20*time.Millisecond)
+	m.ObserveRequest("items/1.json", "GET", 200, 2*time.Second)
+	m.ObserveRequest("", "POST", 404, time.Millisecond)
+	var out strings.Builder
+	m.WriteTo(&out, 12)
+	for _, want := range []string{
+		"# TYPE liege_requests_total counter\n",
+		`liege_requests_total{route="items/1.json",method="GET",status="200"} 2` + "\n",
+		`liege_request_duration_seconds_bucket{route="items/1.json",method="GET",status="200",le="0.01"} 0` + "\n",
+		`liege_request_duration_seconds_bucket{route="items/1.json",method="GET",status="200",le="0.025"} 1` + "\n",
+		`liege_request_duration_seconds_bucket{route="items/1.json",method="GET",status="200",le="+Inf"} 2` + "\n",
+		`liege_request_duration_seconds_count{route="items/1.json",method="GET",status="200"} 2` + "\n",
+		"liege_unmatched_requests_total 1\n",
+		"liege_routes 12\n",
+		"liege_load_errors 2\n",
+		"liege_last_refresh_timestamp_seconds 1.7e+09\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want metrics to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
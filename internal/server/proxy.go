@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// proxiedHeader is the response header set (to the upstream URL)
+// when the request has been forwarded to an upstream server.
+const proxiedHeader = "X-Liege-Proxied"
+
+// proxy forwards the request to the given upstream server
+// and sends the upstream response back.
+func (s *StubServer) proxy(c echo.Context, upstream model.Upstream) error {
+	target, err := url.Parse(upstream.URL)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, "invalid upstream URL")
+	}
+	record := s.config().Record
+	ctx, cancel := context.WithTimeout(c.Request().Context(), s.Config.UpstreamTimeoutDuration())
+	defer cancel()
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.SetXForwarded()
+			if record {
+				r.Out.Header.Del(echo.HeaderAcceptEncoding) // Record uncompressed bodies
+			}
+		},
+		ModifyResponse: func(res *http.Response) error {
+			res.Header.Set(proxiedHeader, upstream.URL)
+			if record {
+				s.record(c.Request(), res)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			message := "upstream request failed"
+			if errors.Is(err, context.DeadlineExceeded) {
+				message = "upstream request timed out"
+			}
+			console.Logger.Println("Error: " + message + " (" + upstream.URL + "): " + err.Error())
+			w.Header().Set(proxiedHeader, upstream.URL)
+			w.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w.WriteHeader(http.StatusBadGateway)
+			_ = json.NewEncoder(w).Encode(map[string]string{"message": message + ": " + err.Error()})
+		},
+	}
+	rp.ServeHTTP(c.Response(), c.Request().WithContext(ctx))
+	return nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"gaelgirodon.fr/liege/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rateLimitBucket counts requests sent by a client to a route during the current window.
+type rateLimitBucket struct {
+	// FilePath is the path to the stub file of the rate limited route.
+	FilePath string `json:"file_path"`
+	// Key identifies the client (IP address or header value).
+	Key string `json:"key"`
+	// Limit is the maximum number of requests in the window.
+	Limit int `json:"limit"`
+	// Count is the number of requests received in the window.
+	Count int `json:"count"`
+	// Reset is the time at which the window ends.
+	Reset time.Time `json:"reset"`
+}
+
+// Remaining returns the number of requests still allowed in the window.
+func (b *rateLimitBucket) Remaining() int {
+	return max(b.Limit-b.Count, 0)
+}
+
+// rateLimiter enforces route rate limits using fixed time windows.
+type rateLimiter struct {
+	// mu guards buckets.
+	mu sync.Mutex
+	// buckets are the rate limit buckets indexed by route file path and client key.
+	buckets map[string]*rateLimitBucket
+}
+
+// Allow counts a request from the given client to the given route and
+// reports whether it is allowed, with a copy of the updated bucket.
+func (l *rateLimiter) Allow(route *model.Route, key string, now time.Time) (rateLimitBucket, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buckets == nil {
+		l.buckets = make(map[string]*rateLimitBucket)
+	}
+	id := route.FilePath + "\x00" + key
+	b, exists := l.buckets[id]
+	if !exists || !now.Before(b.Reset) {
+		b = &rateLimitBucket{FilePath: route.FilePath, Key: key,
+			Limit: route.RateLimit.Limit, Reset: now.Add(route.RateLimit.Duration())}
+		l.buckets[id] = b
+	}
+	if b.Count >= b.Limit {
+		return *b, false
+	}
+	b.Count++
+	return *b, true
+}
+
+// Buckets returns a copy of active buckets sorted by file path and key.
+func (l *rateLimiter) Buckets(now time.Time) []rateLimitBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	buckets := make([]rateLimitBucket, 0, len(l.buckets))
+	for id, b := range l.buckets {
+		if now.Before(b.Reset) {
+			buckets = append(buckets, *b)
+		} else {
+			delete(l.buckets, id) // Expired
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].FilePath != buckets[j].FilePath {
+			return buckets[i].FilePath < buckets[j].FilePath
+		}
+		return buckets[i].Key < buckets[j].Key
+	})
+	return buckets
+}
+
+// Reset clears all buckets.
+func (l *rateLimiter) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buckets = nil
+}
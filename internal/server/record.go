@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bytes"
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/model"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// recordIgnoredHeaders are upstream response headers that are not recorded.
+var recordIgnoredHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Encoding":  true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Date":              true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	proxiedHeader:       true,
+}
+
+// recordRefreshDelay is the delay before refreshing routes after recording
+// (exchanges recorded in the meantime are loaded by a single refresh).
+const recordRefreshDelay = 50 * time.Millisecond
+
+// record writes a proxied exchange into the root directory as a stub file
+// and schedules a refresh of routes to serve it (recording is best-effort:
+// errors are logged and the upstream response is always sent back).
+func (s *StubServer) record(req *http.Request, res *http.Response) {
+	body, err := io.ReadAll(res.Body)
+	// Send back the read part of the body followed by the remaining part (if any)
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+	if err != nil {
+		console.Logger.Println("Error: unable to record " + req.URL.Path + ", " + err.Error())
+		return
+	}
+	if !methodOptPattern.MatchString(req.Method) {
+		console.Logger.Println("Error: unable to record " + req.URL.Path + ", unsupported method " + req.Method)
+		return
+	}
+	route := model.NewRoute()
+	route.Path = req.URL.Path
+	route.Method = req.Method
+	route.Code = res.StatusCode
+	route.ContentType = res.Header.Get(echo.HeaderContentType)
+	route.Content = body
+	for name, values := range req.URL.Query() {
+		if len(values) > 1 { // Routes match a single value per query parameter
+			console.Logger.Println("Error: unable to record " + req.URL.Path + ", repeated query parameter " + name)
+			return
+		}
+		route.QueryParams = append(route.QueryParams, model.QueryParam{Name: name, Value: values[0]})
+	}
+	route.Headers = RecordableHeaders(res.Header)
+	relPath, written, err := WriteStub(s.config().Root, route)
+	if err != nil {
+		console.Logger.Println("Error: unable to record " + req.URL.Path + ", " + err.Error())
+	} else if written {
+		console.Logger.Println("Recorded " + req.Method + " " + req.URL.RequestURI() + " to " + relPath)
+		s.scheduleRecordRefresh()
+	}
+}
+
+// scheduleRecordRefresh refreshes routes in the background after recording,
+// unless a refresh is already scheduled.
+func (s *StubServer) scheduleRecordRefresh() {
+	if s.recordRefresh.Swap(true) {
+		return
+	}
+	time.AfterFunc(recordRefreshDelay, func() {
+		s.recordRefresh.Store(false)
+		if err := s.loadRoutes(); err != nil {
+			console.Logger.Println("Error: unable to refresh routes, " + err.Error())
+		}
+	})
+}
+
+// RecordableHeaders returns response headers to record in a sidecar file
+// (i.e. except content and connection management headers), if any.
+func RecordableHeaders(header http.Header) map[string]string {
+	var headers map[string]string
+	for name, values := range header {
+		if !recordIgnoredHeaders[http.CanonicalHeaderKey(name)] {
+			if headers == nil {
+				headers = make(map[string]string)
+			}
+			headers[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
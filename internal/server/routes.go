@@ -1,46 +1,268 @@
 package server
 
 import (
+	"errors"
 	"gaelgirodon.fr/liege/internal/console"
 	"gaelgirodon.fr/liege/internal/model"
 	"os"
 	paths "path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 )
 
-// BuildRoutes loads stub response files from the given root directory and builds server routes.
-func BuildRoutes(root string) (routes []*model.Route, err error) {
+// BuildRoutes loads stub response files from the given root directory and builds server routes
+// (files that cannot be loaded are skipped and logged, or fail the build in strict mode).
+func BuildRoutes(root string, strict bool) ([]*model.Route, error) {
+	routes, loadErrs, err := buildRoutes(root, nil)
+	if err == nil && strict && len(loadErrs) > 0 {
+		return nil, aggregateLoadErrors(loadErrs)
+	}
+	logLoadErrors(loadErrs)
+	return routes, err
+}
+
+// aggregateLoadErrors returns a single error reporting all stub files that cannot be loaded.
+func aggregateLoadErrors(loadErrs []error) error {
+	msgs := make([]string, 0, len(loadErrs))
+	for _, err := range loadErrs {
+		msgs = append(msgs, err.Error())
+	}
+	return errors.New(strconv.Itoa(len(loadErrs)) + " stub file(s) cannot be loaded: " + strings.Join(msgs, "; "))
+}
+
+// logLoadErrors logs errors that occurred while loading stub files.
+func logLoadErrors(loadErrs []error) {
+	for _, err := range loadErrs {
+		console.Logger.Println("Error: " + err.Error())
+	}
+}
+
+// buildConfigRoutes builds routes from the root directory (if any), mounted root
+// directories, layers and profiles (see buildRoutes) and merges them in evaluation
+// order: mounted routes have their URL path prefixed and their file path prefixed
+// with the mount directory, layers are applied in order (see applyLayer), and
+// profile routes are appended (see buildProfileRoutes).
+func buildConfigRoutes(config *model.Config) (routes []*model.Route, loadErrs []error, err error) {
+	if len(config.Root) > 0 {
+		if routes, loadErrs, err = buildRoutes(config.Root, config.Directories); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, mount := range config.Mounts {
+		mountRoutes, mountErrs, err := buildRoutes(mount.Root, config.Directories)
+		if err != nil {
+			return nil, nil, errors.New("mount " + mount.Prefix + ": " + err.Error())
+		}
+		loadErrs = append(loadErrs, mountErrs...)
+		for _, route := range mountRoutes {
+			route.Path = mount.Path(route.Path)
+			route.FilePath = paths.Join(filepath.ToSlash(mount.Root), filepath.ToSlash(route.FilePath))
+		}
+		routes = append(routes, mountRoutes...)
+	}
+	relPaths := make(map[*model.Route]string, len(routes))
+	for _, layer := range config.Layers {
+		if routes, loadErrs, err = applyLayer(routes, loadErrs, relPaths, layer, config.Directories); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(config.Root) > 0 {
+		profiles, profileErrs, err := buildProfileRoutes(config.Root, config.Directories)
+		if err != nil {
+			return nil, nil, err
+		}
+		routes, loadErrs = append(routes, profiles...), append(loadErrs, profileErrs...)
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Before(*routes[j])
+	})
+	return routes, loadErrs, nil
+}
+
+// buildProfileRoutes builds the routes of each profile subdirectory of the profiles
+// directory in the given root directory (see buildRoutes) with their profile name
+// and their file path relative to the root directory.
+func buildProfileRoutes(root string, dirs model.Directories) (routes []*model.Route, loadErrs []error, err error) {
+	entries, err := os.ReadDir(filepath.Join(root, profilesDirName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, errors.New("unable to read " + profilesDirName + " directory")
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		profile, profileErrs, err := buildRoutes(filepath.Join(root, profilesDirName, entry.Name()), dirs)
+		if err != nil {
+			return nil, nil, errors.New("profile " + entry.Name() + ": " + err.Error())
+		}
+		for _, route := range profile {
+			route.FilePath = paths.Join(profilesDirName, entry.Name(), filepath.ToSlash(route.FilePath))
+			route.Profile = entry.Name()
+		}
+		routes, loadErrs = append(routes, profile...), append(loadErrs, profileErrs...)
+	}
+	return routes, loadErrs, nil
+}
+
+// profileRoutes returns the routes to match a request for the given profile
+// (if any) in evaluation order: profile routes first, then base routes.
+func profileRoutes(routes []*model.Route, profile string) []*model.Route {
+	selected := make([]*model.Route, 0, len(routes))
+	if len(profile) > 0 {
+		for _, route := range routes {
+			if route.Profile == profile {
+				selected = append(selected, route)
+			}
+		}
+	}
+	for _, route := range routes {
+		if len(route.Profile) == 0 {
+			selected = append(selected, route)
+		}
+	}
+	return selected
+}
+
+// indexProfiles returns the routes to match requests in evaluation order
+// indexed by profile (routes of requests without a profile at index "").
+func indexProfiles(routes []*model.Route) map[string][]*model.Route {
+	profiles := map[string][]*model.Route{"": profileRoutes(routes, "")}
+	for _, route := range routes {
+		if _, ok := profiles[route.Profile]; !ok {
+			profiles[route.Profile] = profileRoutes(routes, route.Profile)
+		}
+	}
+	return profiles
+}
+
+// applyLayer builds routes from a layer directory and overlays them on top of the
+// given routes: routes matching the same requests as a layer route and routes of
+// stub files deleted by a tombstone file in the layer are removed. Layer routes
+// have their file path prefixed with the layer directory (relPaths keeps file paths
+// relative to their directory for lower layers routes).
+func applyLayer(routes []*model.Route, loadErrs []error, relPaths map[*model.Route]string,
+	layer string, dirs model.Directories) ([]*model.Route, []error, error) {
+	layerRoutes, layerErrs, err := buildRoutes(layer, dirs)
+	if err != nil {
+		return nil, nil, errors.New("layer " + layer + ": " + err.Error())
+	}
+	deleted, err := findTombstones(layer)
+	if err != nil {
+		return nil, nil, errors.New("layer " + layer + ": " + err.Error())
+	}
+	replaced := make(map[string]bool, len(layerRoutes))
+	for _, route := range layerRoutes {
+		replaced[route.Signature()] = true
+	}
+	merged := make([]*model.Route, 0, len(routes)+len(layerRoutes))
+	for _, route := range routes {
+		relPath, exists := relPaths[route]
+		if !exists {
+			relPath = route.FilePath
+		}
+		if !replaced[route.Signature()] && !deleted[relPath] {
+			merged = append(merged, route)
+		}
+	}
+	for _, route := range layerRoutes {
+		relPaths[route] = filepath.ToSlash(route.FilePath)
+		route.FilePath = paths.Join(filepath.ToSlash(layer), filepath.ToSlash(route.FilePath))
+		route.Layer = layer
+	}
+	return append(merged, layerRoutes...), append(loadErrs, layerErrs...), nil
+}
+
+// findTombstones returns the paths (relative to the directory, slash-separated)
+// of the stub files deleted by tombstone files in the given directory.
+func findTombstones(dir string) (map[string]bool, error) {
+	deleted := make(map[string]bool)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, tombstoneSuffix) {
+			return nil
+		}
+		if relPath, err := filepath.Rel(dir, strings.TrimSuffix(path, tombstoneSuffix)); err == nil {
+			deleted[filepath.ToSlash(relPath)] = true
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// buildRoutes loads stub response files from the given root directory and builds
+// server routes (applying directory default options), and returns errors for
+// files that cannot be loaded (skipped).
+func buildRoutes(root string, dirs model.Directories) (routes []*model.Route, loadErrs []error, err error) {
+	types, err := loadContentTypes(root)
+	if err != nil {
+		return nil, nil, err
+	}
 	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			console.Logger.Println("Error: unable to access " + path)
+			loadErrs = append(loadErrs, errors.New("unable to access "+path))
 			return nil
 		}
 		if info.IsDir() {
+			if filepath.Dir(path) == filepath.Clean(root) && info.Name() == profilesDirName {
+				return filepath.SkipDir // Profile routes are built separately
+			}
 			// Only serve regular files
 			return nil
 		}
 		// Get the relative path to build the URL
 		relPath, err := filepath.Rel(root, path)
 		if err != nil {
-			console.Logger.Println("Error: unable to load " + path)
+			loadErrs = append(loadErrs, errors.New("unable to load "+path))
+			return nil
+		}
+		if isReservedFile(relPath) {
+			console.Logger.Println("Not serving " + path + ", reserved file name")
+			return nil
+		} else if isMetaFile(relPath) {
+			// Sidecar and tombstone files are not served
 			return nil
 		}
 		// Parse file name
-		name, ext, route, err := parseFileName(info.Name())
+		name, ext, route, err := parseFileName(info.Name(), types)
 		if err != nil {
-			console.Logger.Println("Error: unable to load " + path + ", " + err.Error())
+			loadErrs = append(loadErrs, errors.New("unable to load "+path+", "+err.Error()))
 			return nil
 		}
 		// Build base URL
 		baseUrl := strings.Trim(filepath.ToSlash(filepath.Dir(relPath)), "/.")
 		// Load file and guess content type
-		content, contentType, err := readFile(path)
+		meta, err := readSidecar(path)
+		if err != nil {
+			loadErrs = append(loadErrs, errors.New("unable to load "+path+", "+err.Error()))
+			return nil
+		}
+		content, contentType, err := readFile(path, route.ContentType, types)
 		if err != nil {
-			console.Logger.Println("Error: " + err.Error())
+			loadErrs = append(loadErrs, err)
 			return nil
 		}
+		if len(meta.ContentType) > 0 {
+			contentType = meta.ContentType // Exact content type from the sidecar file
+		}
+		route.QueryParams = append(route.QueryParams, meta.QueryParams...)
+		// Apply directory default options not set in the file name
+		defaults := dirs.Defaults(baseUrl)
+		if route.Latency.IsDisabledOrUndefined() {
+			route.Latency = defaults.Latency
+		}
+		if !route.RateLimit.IsEnabled() {
+			route.RateLimit = defaults.RateLimit
+		}
+		route.Headers = defaults.Headers
+		for name, value := range meta.Headers {
+			if route.Headers == nil {
+				route.Headers = make(map[string]string)
+			}
+			route.Headers[name] = value // Sidecar headers override default headers
+		}
 		// 1st route: path without extension
 		url := "/" + paths.Join(baseUrl, name)
 		routes = append(routes, route.With(relPath, url, content, contentType))
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_buildRoutes_directories(t *testing.T) {
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "api", "slow"), 0755)
+	_ = os.WriteFile(filepath.Join(root, console.ConfigFileName), []byte("{}"), 0644)
+	_ = os.WriteFile(filepath.Join(root, "api", "items__l5.json"), []byte("[]"), 0644)
+	_ = os.WriteFile(filepath.Join(root, "api", "slow", "items.json"), []byte("[]"), 0644)
+	_ = os.WriteFile(filepath.Join(root, "api", "slow", "items.json"+sidecarSuffix),
+		[]byte(`{"headers":{"X-Version":"2"}}`), 0644)
+	dirs := model.Directories{
+		"api":      {Latency: model.Latency{Min: 100, Max: 100}, Headers: map[string]string{"X-Version": "1", "X-Api": "true"}},
+		"api/slow": {Latency: model.Latency{Min: 1000, Max: 2000}, RateLimit: model.RateLimit{Limit: 1, Period: 1}},
+	}
+	routes, loadErrs, err := buildRoutes(root, dirs)
+	if err != nil || len(loadErrs) > 0 || len(routes) != 4 { // The configuration file is not served
+		t.Fatalf("want 4 routes, got %d (%v %v)", len(routes), err, loadErrs)
+	}
+	for _, r := range routes {
+		if r.Path == "/api/items" && (r.Latency != model.Latency{Min: 5, Max: 5} || r.Headers["X-Version"] != "1") {
+			t.Errorf("want file name latency and directory headers, got %+v", r)
+		} else if r.Path == "/api/slow/items" && (r.Latency != model.Latency{Min: 1000, Max: 2000} ||
+			!r.RateLimit.IsEnabled() || r.Headers["X-Version"] != "2" || r.Headers["X-Api"] != "true") {
+			t.Errorf("want closest directory defaults and sidecar headers, got %+v", r)
+		}
+	}
+}
+
+func Test_buildConfigRoutes_mounts(t *testing.T) {
+	root, shared := t.TempDir(), t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "items.json"), []byte("[]"), 0644)
+	_ = os.WriteFile(filepath.Join(shared, "index.json"), []byte("{}"), 0644)
+	_ = os.WriteFile(filepath.Join(shared, "login__POST.json"), []byte("{}"), 0644)
+	config := &model.Config{Root: root, Mounts: []model.Mount{{Prefix: "/auth", Root: shared}}}
+	routes, _, err := buildConfigRoutes(config)
+	if err != nil || len(routes) != 7 {
+		t.Fatalf("want 7 routes, got %d (%v)", len(routes), err)
+	}
+	paths := make(map[string]string)
+	for _, r := range routes {
+		paths[r.Path] = r.FilePath
+	}
+	for path, filePath := range map[string]string{"/items": "items.json", "/auth": filepath.ToSlash(shared) + "/index.json",
+		"/auth/index.json": filepath.ToSlash(shared) + "/index.json", "/auth/login": filepath.ToSlash(shared) + "/login__POST.json"} {
+		if paths[path] != filePath {
+			t.Errorf("want route %s for %s, got %v", path, filePath, paths)
+		}
+	}
+	for i := 1; i < len(routes); i++ {
+		if routes[i].Before(*routes[i-1]) {
+			t.Errorf("want routes sorted in evaluation order, got %s before %s", routes[i-1].Path, routes[i].Path)
+		}
+	}
+}
+
+func Test_buildConfigRoutes_layers(t *testing.T) {
+	root, degraded, feature := t.TempDir(), t.TempDir(), t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "items.json"), []byte("[]"), 0644)
+	_ = os.WriteFile(filepath.Join(root, "users.json"), []byte("[]"), 0644)
+	_ = os.WriteFile(filepath.Join(root, "health.json"), []byte("{}"), 0644)
+	_ = os.WriteFile(filepath.Join(degraded, "items__503.json"), []byte("{}"), 0644)
+	_ = os.WriteFile(filepath.Join(degraded, "users.json"+tombstoneSuffix), nil, 0644)
+	_ = os.WriteFile(filepath.Join(feature, "items__503.json"+tombstoneSuffix), nil, 0644)
+	config := &model.Config{Root: root, Layers: []string{degraded, feature}}
+	routes, _, err := buildConfigRoutes(config)
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	layers := make(map[string]string)
+	for _, r := range routes {
+		layers[r.Path] = r.Layer
+	}
+	// items is replaced by the degraded layer and then deleted by the feature layer,
+	// users is deleted by the degraded layer, and health is kept from the root directory
+	if len(routes) != 2 || layers["/health"] != "" || layers["/health.json"] != "" {
+		t.Errorf("want only health routes, got %v", layers)
+	}
+	config.Layers = config.Layers[:1]
+	routes, _, _ = buildConfigRoutes(config)
+	for _, r := range routes {
+		if r.Path == "/items" && (r.Code != 503 || r.Layer != degraded ||
+			r.FilePath != filepath.ToSlash(degraded)+"/items__503.json") {
+			t.Errorf("want items route from the degraded layer, got %+v", r)
+		}
+	}
+	if len(routes) != 4 {
+		t.Errorf("want 4 routes, got %d", len(routes))
+	}
+}
+
+func Test_buildConfigRoutes_profiles(t *testing.T) {
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, profilesDirName, "outage"), 0755)
+	_ = os.WriteFile(filepath.Join(root, "items.json"), []byte("[]"), 0644)
+	_ = os.WriteFile(filepath.Join(root, profilesDirName, "outage", "items__503.json"), []byte("{}"), 0644)
+	routes, _, err := buildConfigRoutes(&model.Config{Root: root})
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if len(routes) != 4 {
+		t.Fatalf("want 4 routes, got %d", len(routes))
+	}
+	selected := profileRoutes(routes, "outage")
+	if len(selected) != 4 || selected[0].Profile != "outage" || selected[0].Code != 503 ||
+		selected[0].FilePath != profilesDirName+"/outage/items__503.json" || selected[2].Profile != "" {
+		t.Errorf("want profile routes first, then base routes, got %+v", selected)
+	}
+	if selected = profileRoutes(routes, "unknown"); len(selected) != 2 || selected[0].Profile != "" {
+		t.Errorf("want only base routes for an unknown profile, got %+v", selected)
+	}
+	if profiles := indexProfiles(routes); len(profiles) != 2 || len(profiles[""]) != 2 || len(profiles["outage"]) != 4 {
+		t.Errorf("want base and outage profile routes indexed, got %+v", profiles)
+	}
+}
+
+func TestBuildRoutes_strict(t *testing.T) {
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "items.json"), []byte("[]"), 0644)
+	_ = os.WriteFile(filepath.Join(root, "users__GTE.json"), []byte("[]"), 0644)
+	if routes, err := BuildRoutes(root, false); err != nil || len(routes) != 2 {
+		t.Errorf("want 2 routes and no error, got %d routes and %v", len(routes), err)
+	}
+	if _, err := BuildRoutes(root, true); err == nil || !strings.Contains(err.Error(), "'GTE'") {
+		t.Errorf("want an aggregated error, got %v", err)
+	}
+	s := &StubServer{Config: model.Config{Root: root, Strict: true}}
+	if err := s.loadRoutes(); err == nil || len(s.getLoadErrors()) != 1 {
+		t.Errorf("want the load to fail with 1 load error, got %v", err)
+	}
+	if s.metrics.loadErrors != 1 || !s.metrics.lastRefresh.IsZero() {
+		t.Errorf("want 1 load error and no successful refresh in metrics, got %d and %v",
+			s.metrics.loadErrors, s.metrics.lastRefresh)
+	}
+	s.Config.Strict = false
+	if err := s.loadRoutes(); err != nil || len(s.getRoutes()) != 2 || len(s.getLoadErrors()) != 1 {
+		t.Errorf("want 2 routes and 1 load error, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"gaelgirodon.fr/liege/internal/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// mergeRoutes removes expired runtime routes and merges file and runtime
+// routes in evaluation order (must be called with the lock held).
+func (s *StubServer) mergeRoutes(now time.Time) {
+	routes := make([]*model.Route, 0, len(s.fileRoutes)+len(s.runtimeRoutes))
+	routes = append(routes, s.fileRoutes...)
+	s.nextExpiry = time.Time{}
+	for id, route := range s.runtimeRoutes {
+		if route.IsExpired(now) {
+			delete(s.runtimeRoutes, id)
+			continue
+		}
+		if !route.Expires.IsZero() && (s.nextExpiry.IsZero() || route.Expires.Before(s.nextExpiry)) {
+			s.nextExpiry = route.Expires
+		}
+		routes = append(routes, route)
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Before(*routes[j])
+	})
+	s.routes = routes
+	s.profiles = indexProfiles(routes)
+}
+
+// putRuntimeRoute adds or replaces a runtime route, and reports whether
+// a route with the same identifier already existed (if allowed to replace it).
+func (s *StubServer) putRuntimeRoute(route *model.Route, replace bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	existing, exists := s.runtimeRoutes[route.ID]
+	if exists = exists && !existing.IsExpired(now); exists && !replace {
+		return true
+	}
+	if s.runtimeRoutes == nil {
+		s.runtimeRoutes = make(map[string]*model.Route)
+	}
+	s.runtimeRoutes[route.ID] = route
+	s.mergeRoutes(now)
+	return exists
+}
+
+// bindRouteDefinition binds and validates a runtime route definition
+// (with the given identifier from the URL path, if set).
+func bindRouteDefinition(c echo.Context, id string) (*model.RouteDefinition, error) {
+	def := new(model.RouteDefinition)
+	if err := c.Bind(def); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid body")
+	} else if len(id) > 0 && len(def.ID) > 0 && def.ID != id {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "route id mismatch")
+	} else if len(id) > 0 {
+		def.ID = id
+	}
+	if err := def.Validate(); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return def, nil
+}
+
+// createRouteHandler creates a runtime route.
+func (s *StubServer) createRouteHandler(c echo.Context) error {
+	def, err := bindRouteDefinition(c, "")
+	if err != nil {
+		return err
+	}
+	if len(def.ID) == 0 {
+		id := make([]byte, 8)
+		_, _ = rand.Read(id)
+		def.ID = hex.EncodeToString(id)
+	}
+	route := def.Route(time.Now())
+	if exists := s.putRuntimeRoute(route, false); exists {
+		return echo.NewHTTPError(http.StatusConflict, "route '"+def.ID+"' already exists")
+	}
+	return c.JSON(http.StatusCreated, route)
+}
+
+// updateRouteHandler creates or replaces a runtime route.
+func (s *StubServer) updateRouteHandler(c echo.Context) error {
+	def, err := bindRouteDefinition(c, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	route := def.Route(time.Now())
+	if exists := s.putRuntimeRoute(route, true); !exists {
+		return c.JSON(http.StatusCreated, route)
+	}
+	return c.JSON(http.StatusOK, route)
+}
+
+// deleteRouteHandler deletes a runtime route.
+func (s *StubServer) deleteRouteHandler(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	route, exists := s.runtimeRoutes[c.Param("id")]
+	if !exists || route.IsExpired(time.Now()) {
+		return echo.NewHTTPError(http.StatusNotFound, "route '"+c.Param("id")+"' not found")
+	}
+	delete(s.runtimeRoutes, route.ID)
+	s.mergeRoutes(time.Now())
+	return c.NoContent(http.StatusNoContent)
+}
+
+// deleteRoutesHandler deletes all runtime routes.
+func (s *StubServer) deleteRoutesHandler(c echo.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runtimeRoutes = nil
+	s.mergeRoutes(time.Now())
+	return c.NoContent(http.StatusNoContent)
+}
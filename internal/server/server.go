@@ -3,61 +3,209 @@ package server
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"gaelgirodon.fr/liege/internal/console"
 	"gaelgirodon.fr/liege/internal/model"
+	"gaelgirodon.fr/liege/internal/openapi"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
 	"io"
+	"maps"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	// Request body with size higher than maxRequestBodySize
-	// won't be sent back in a header.
-	maxRequestBodySize = 4096
 	// requestBodyHeader is the request body header name in the response.
 	requestBodyHeader = "X-Request-Body"
+	// requestHeaderPrefix is the prefix of request headers sent back in the response.
+	requestHeaderPrefix = "X-Request-Header-"
+	// rateLimitLimitHeader is the response header with the rate limit quota.
+	rateLimitLimitHeader = "RateLimit-Limit"
+	// rateLimitRemainingHeader is the response header with the remaining quota.
+	rateLimitRemainingHeader = "RateLimit-Remaining"
+	// rateLimitResetHeader is the response header with the seconds until the quota resets.
+	rateLimitResetHeader = "RateLimit-Reset"
+	// profileHeader is the request header selecting the profile of routes to match first.
+	profileHeader = "X-Liege-Profile"
 )
 
 // StubServer is an HTTP server for stub files.
 type StubServer struct {
 	// Config is the application configuration.
 	Config model.Config
-	// routes it the stub routes list.
+	// routes it the stub routes list (file and runtime routes in evaluation order).
 	routes []*model.Route
+	// profiles are the routes to match requests in evaluation order indexed
+	// by profile (routes of requests without a known profile at index "").
+	profiles map[string][]*model.Route
+	// fileRoutes are the routes built from stub files.
+	fileRoutes []*model.Route
+	// runtimeRoutes are the routes created at runtime indexed by identifier.
+	runtimeRoutes map[string]*model.Route
+	// nextExpiry is the time at which the next runtime route expires (if any).
+	nextExpiry time.Time
+	// loaded indicates whether routes have been built at least once.
+	loaded bool
+	// loadErr is the error of the last refresh (if it failed).
+	loadErr error
+	// loadErrs are the errors of stub files that could not be loaded during the last refresh.
+	loadErrs []error
+	// mu guards routes, profiles, fileRoutes, runtimeRoutes, nextExpiry, loaded, loadErr, loadErrs
+	// and configuration values updatable at runtime.
+	mu sync.RWMutex
+	// rateLimiter tracks requests to rate limited routes.
+	rateLimiter rateLimiter
+	// spec is the OpenAPI document requests are validated against (if any).
+	spec *openapi.Document
+	// journal keeps the last received stub requests.
+	journal journal
+	// metrics collects stub server metrics.
+	metrics metrics
+	// watcher notifies changes of stub files (in watch mode).
+	watcher fileWatcher
+	// recordRefresh indicates whether a refresh of routes is scheduled after recording.
+	recordRefresh atomic.Bool
 }
 
 // Start starts the stub server.
 func (s *StubServer) Start() error {
 	// Setup HTTP server
-	e := echo.New()
-	e.HideBanner = true
-	e.HidePort = true
-	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
-	e.Pre(middleware.RemoveTrailingSlash())
+	e := newEcho()
+	// Load the OpenAPI document to validate requests against
+	var err error
+	if len(s.Config.Spec) > 0 {
+		if s.spec, err = openapi.Load(s.Config.Spec); err != nil {
+			return err
+		}
+	}
 	// Load stub files and build routes
-	routes, err := BuildRoutes(s.Config.Root)
-	if err != nil {
+	if err = s.loadRoutes(); err != nil {
 		return err
 	}
-	s.routes = routes
-	// Register first-level routes
-	e.GET("/_liege/config", s.getConfigHandler)
-	e.PUT("/_liege/config", s.updateConfigHandler)
-	e.POST("/_liege/refresh", s.refreshHandler)
-	e.GET("/_liege/routes", s.routesHandler)
+	// Reload stub files when they change
+	if s.Config.Watch {
+		go s.watch()
+	}
+	// Register management routes (on a separate listener if enabled)
+	prefix := s.Config.ManagementPrefix()
+	errs := make(chan error, 2)
+	if s.Config.MgmtPort > 0 {
+		m := newEcho()
+		s.registerUIRoutes(m.Group(prefix + "/ui"))
+		s.registerProbeRoutes(m.Group(prefix))
+		s.registerManagementRoutes(m.Group(prefix, s.managementMiddlewares(true)...))
+		go func() {
+			errs <- s.listen(m, "Management", s.Config.ManagementAddress(), s.Config.MgmtPort)
+		}()
+	} else {
+		s.registerUIRoutes(e.Group(prefix+"/ui", s.uiMiddlewares()...))
+		s.registerProbeRoutes(e.Group(prefix, s.uiMiddlewares()...))
+		s.registerManagementRoutes(e.Group(prefix, s.managementMiddlewares(false)...))
+	}
+	// Register stub routes
 	e.Any("/*", s.stubsHandler)
 	// Start
+	go func() {
+		errs <- s.listen(e, "", s.Config.Address(), s.Config.Port)
+	}()
+	return <-errs
+}
+
+// listen starts a named HTTP server on the given address (with TLS if configured).
+func (s *StubServer) listen(e *echo.Echo, name string, address string, port uint16) error {
 	if s.Config.HasTLS() {
-		console.Logger.Printf("HTTPS server started on port %d\n\n", s.Config.Port)
-		err = e.StartTLS(s.Config.Address(), s.Config.Cert, s.Config.Key)
+		console.Logger.Printf("%s server started on port %d\n\n", strings.TrimSpace(name+" HTTPS"), port)
+		return e.StartTLS(address, s.Config.Cert, s.Config.Key)
+	}
+	console.Logger.Printf("%s server started on port %d\n\n", strings.TrimSpace(name+" HTTP"), port)
+	return e.Start(address)
+}
+
+// loadRoutes loads stub files from the root directory and replaces routes.
+func (s *StubServer) loadRoutes() error {
+	start := time.Now()
+	config := s.config()
+	routes, loadErrs, err := buildConfigRoutes(&config)
+	if err == nil {
+		s.metrics.ObserveLoadErrors(len(loadErrs))
+	}
+	if err == nil && config.Strict && len(loadErrs) > 0 {
+		err = aggregateLoadErrors(loadErrs)
 	} else {
-		console.Logger.Printf("HTTP server started on port %d\n\n", s.Config.Port)
-		err = e.Start(s.Config.Address())
+		logLoadErrors(loadErrs)
 	}
-	return err
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadErrs = loadErrs
+	if s.loadErr = err; err != nil {
+		return err
+	}
+	s.metrics.ObserveRefresh(start)
+	s.loaded = true
+	s.fileRoutes = routes
+	s.mergeRoutes(time.Now())
+	return nil
+}
+
+// isReady indicates whether routes have been built and the last refresh succeeded,
+// or returns the reason why the server is not ready.
+func (s *StubServer) isReady() (bool, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.loaded {
+		return false, "routes are not loaded yet"
+	} else if s.loadErr != nil {
+		return false, "last refresh failed: " + s.loadErr.Error()
+	}
+	return true, ""
+}
+
+// getLoadErrors returns the errors of stub files that could not be loaded during the last refresh.
+func (s *StubServer) getLoadErrors() []error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadErrs
+}
+
+// getRoutes returns the current routes (removing expired runtime routes).
+func (s *StubServer) getRoutes() []*model.Route {
+	now := time.Now()
+	s.mu.RLock()
+	routes, nextExpiry := s.routes, s.nextExpiry
+	s.mu.RUnlock()
+	if nextExpiry.IsZero() || now.Before(nextExpiry) {
+		return routes
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mergeRoutes(now)
+	return s.routes
+}
+
+// getProfileRoutes returns the current routes to match a request for the
+// given profile (if any) in evaluation order (removing expired runtime routes).
+func (s *StubServer) getProfileRoutes(profile string) []*model.Route {
+	s.getRoutes()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if routes, ok := s.profiles[profile]; ok {
+		return routes
+	}
+	return s.profiles[""]
+}
+
+// config returns a copy of the configuration (values updatable at runtime
+// must be read from a copy to not race with configuration updates).
+func (s *StubServer) config() model.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Config
 }
 
 //
@@ -66,62 +214,255 @@ func (s *StubServer) Start() error {
 
 // getConfigHandler gets the stub server configuration.
 func (s *StubServer) getConfigHandler(c echo.Context) error {
-	return c.JSON(http.StatusOK, s.Config)
+	return c.JSON(http.StatusOK, s.config())
 }
 
 // updateConfigHandler updates the stub server configuration.
 func (s *StubServer) updateConfigHandler(c echo.Context) error {
 	config := new(model.Config)
+	current := s.config()
 	if err := c.Bind(config); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid body")
-	} else if err := console.ValidateRootDirPath(config.Root); err != nil {
+	} else if err := console.ValidateRootDirPath(config.Root); err != nil &&
+		(len(config.Root) > 0 || len(current.Mounts) == 0) { // The root directory is optional with mounts
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	} else if !config.Latency.IsValid() {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid latency value")
 	}
+	for _, upstream := range config.Upstreams {
+		if !upstream.IsValid() {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid upstream value")
+		}
+	}
+	for _, layer := range config.Layers {
+		if err := console.ValidateRootDirPath(layer); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid layer "+layer+": "+err.Error())
+		}
+	}
+	rootChanged := config.Root != current.Root
+	layersChanged := !slices.Equal(config.Layers, current.Layers)
+	s.mu.Lock()
 	s.Config.Root = config.Root
 	s.Config.Latency = config.Latency
+	s.Config.Upstreams = config.Upstreams
+	s.Config.Record = config.Record
+	s.Config.Diagnostics = config.Diagnostics
+	s.Config.Layers = config.Layers
+	if s.Config.Sources != nil {
+		// Copy the sources to not modify the map of configuration copies being read
+		sources := maps.Clone(s.Config.Sources)
+		for _, key := range []string{"root", "latency", "upstreams", "record", "diagnostics", "layers"} {
+			sources[key] = model.SourceAPI
+		}
+		s.Config.Sources = sources
+	}
+	updated := s.Config
+	s.mu.Unlock()
+	if updated.Watch && (rootChanged || layersChanged) {
+		s.watcher.Start(updated.RootDirs(), updated.WatchPoll)
+	}
+	if layersChanged { // Switch layers right away
+		if err := s.loadRoutes(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				"unable to load stub files and build routes: "+err.Error())
+		}
+	}
 	return c.NoContent(http.StatusNoContent)
 }
 
-// refreshHandler reloads stub files and re-builds routes.
+// refreshHandler reloads stub files and re-builds routes, and returns the
+// errors of stub files that could not be loaded.
 func (s *StubServer) refreshHandler(c echo.Context) error {
-	if routes, err := BuildRoutes(s.Config.Root); err != nil {
+	if err := s.loadRoutes(); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest,
 			"unable to load stub files and build routes: "+err.Error())
-	} else {
-		s.routes = routes
 	}
-	return c.NoContent(http.StatusNoContent)
+	return s.loadErrorsHandler(c)
+}
+
+// loadErrorsHandler returns the errors of stub files that could not be loaded during the last refresh.
+func (s *StubServer) loadErrorsHandler(c echo.Context) error {
+	loadErrs := s.getLoadErrors()
+	msgs := make([]string, 0, len(loadErrs))
+	for _, err := range loadErrs {
+		msgs = append(msgs, err.Error())
+	}
+	return c.JSON(http.StatusOK, msgs)
 }
 
 // routesHandler returns current registered routes.
 func (s *StubServer) routesHandler(c echo.Context) error {
-	return c.JSON(http.StatusOK, s.routes)
+	if profile := c.QueryParam("profile"); len(profile) > 0 {
+		return c.JSON(http.StatusOK, s.getProfileRoutes(profile))
+	}
+	return c.JSON(http.StatusOK, s.getRoutes())
+}
+
+// metricsHandler returns metrics in the Prometheus text exposition format.
+func (s *StubServer) metricsHandler(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, metricsContentType)
+	c.Response().WriteHeader(http.StatusOK)
+	s.metrics.WriteTo(c.Response(), len(s.getRoutes()))
+	return nil
 }
 
-// stubsHandler handles stub requests using the registered routes.
+// openAPIHandler returns an OpenAPI document describing current registered routes.
+func (s *StubServer) openAPIHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, openapi.FromRoutes(s.getProfileRoutes(""),
+		openapi.Info{Title: console.AppName + " stubs", Version: console.Version}))
+}
+
+// getRateLimitsHandler returns the state of active rate limit buckets.
+func (s *StubServer) getRateLimitsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.rateLimiter.Buckets(time.Now()))
+}
+
+// resetRateLimitsHandler resets all rate limit buckets.
+func (s *StubServer) resetRateLimitsHandler(c echo.Context) error {
+	s.rateLimiter.Reset()
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getRequestsHandler returns the journal entries selected by query parameters.
+func (s *StubServer) getRequestsHandler(c echo.Context) error {
+	filter, err := parseJournalFilter(c.QueryParams())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, s.journal.Entries(filter))
+}
+
+// clearRequestsHandler removes all journal entries.
+func (s *StubServer) clearRequestsHandler(c echo.Context) error {
+	s.journal.Clear()
+	return c.NoContent(http.StatusNoContent)
+}
+
+// echoHandler sends the full request back as JSON.
+func (s *StubServer) echoHandler(c echo.Context) error {
+	var reqBody []byte
+	if c.Request().Body != nil {
+		reqBody, _ = io.ReadAll(c.Request().Body)
+	}
+	return c.JSON(http.StatusOK, model.NewRequest(c.Request(), reqBody))
+}
+
+// stubsHandler handles stub requests and records them in the journal.
 func (s *StubServer) stubsHandler(c echo.Context) error {
-	for _, route := range s.routes {
+	body, truncated := readBodyPrefix(c.Request(), maxJournalBodySize)
+	entry := journalEntry{Timestamp: time.Now(), Request: model.NewRequest(c.Request(), body), BodyTruncated: truncated}
+	err := s.serveStub(c, &entry)
+	entry.Status = c.Response().Status
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		entry.Status = httpErr.Code
+	}
+	s.journal.Add(entry, s.Config.MaxJournalSize())
+	route := entry.FilePath
+	if len(entry.RouteID) > 0 {
+		route = "route " + entry.RouteID
+	}
+	s.metrics.ObserveRequest(route, entry.Method, entry.Status, time.Since(entry.Timestamp))
+	return err
+}
+
+// serveStub serves a stub request using the registered routes
+// and sets the matched route and applied latency on the journal entry.
+func (s *StubServer) serveStub(c echo.Context, entry *journalEntry) error {
+	if valid, err := s.validateRequest(c); !valid {
+		return err
+	}
+	config := s.config()
+	for _, route := range s.getProfileRoutes(c.Request().Header.Get(profileHeader)) {
 		if !route.Match(c) {
 			continue
 		}
-		var reqBody []byte
-		if c.Request().Body != nil && c.Request().ContentLength > 0 {
-			reqBody, _ = io.ReadAll(c.Request().Body)                   // Read request body
-			c.Request().Body = io.NopCloser(bytes.NewBuffer(reqBody))   // Reset request body
-			if len(reqBody) > 0 && len(reqBody) <= maxRequestBodySize { // Set as a response header
-				c.Response().Header().Set(requestBodyHeader, base64.StdEncoding.EncodeToString(reqBody))
-			}
+		entry.FilePath, entry.RouteID = route.FilePath, route.ID
+		if route.RateLimit.IsEnabled() && !s.checkRateLimit(c, route) {
+			return c.NoContent(http.StatusTooManyRequests)
 		}
-		latency := route.Latency.Compute(s.Config.Latency)
+		s.echoRequest(c)
+		latency := route.Latency.Compute(config.Latency)
 		if latency > 0 {
+			entry.Latency = latency.Milliseconds()
 			time.Sleep(latency)
 		}
+		for name, value := range route.Headers {
+			c.Response().Header().Set(name, value)
+		}
 		if len(route.Content) == 0 {
 			return c.NoContent(route.Code)
 		}
 		return c.Blob(route.Code, route.ContentType, route.Content)
 	}
-	return c.NoContent(http.StatusNotFound)
+	if upstream, found := config.Upstream(c.Request().URL.Path); found {
+		return s.proxy(c, upstream)
+	}
+	return s.notFound(c)
+}
+
+// echoRequest sends the request body and, if enabled,
+// the request headers back in response headers.
+func (s *StubServer) echoRequest(c echo.Context) {
+	req := c.Request()
+	if maxSize := s.Config.MaxRequestBodySize(); req.Body != nil && req.ContentLength > 0 && maxSize > 0 {
+		reqBody, truncated := readBodyPrefix(req, maxSize)
+		if len(reqBody) > 0 && !truncated { // Set as a response header
+			c.Response().Header().Set(requestBodyHeader, base64.StdEncoding.EncodeToString(reqBody))
+		}
+	}
+	if s.Config.EchoHeaders {
+		for name, values := range req.Header {
+			c.Response().Header().Set(requestHeaderPrefix+name, strings.Join(values, ", "))
+		}
+	}
+}
+
+// readBody reads the request body and resets it to be read again.
+func readBody(req *http.Request) []byte {
+	if req.Body == nil {
+		return nil
+	}
+	body, _ := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewBuffer(body))
+	return body
+}
+
+// readBodyPrefix reads at most n bytes of the request body, resets the body to be
+// read again entirely and reports whether the body is longer than n bytes.
+func readBodyPrefix(req *http.Request, n int) ([]byte, bool) {
+	if req.Body == nil {
+		return nil, false
+	}
+	prefix, _ := io.ReadAll(io.LimitReader(req.Body, int64(n)+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), req.Body), req.Body}
+	if len(prefix) > n {
+		return prefix[:n], true
+	}
+	return prefix, false
+}
+
+// checkRateLimit counts the request against the route rate limit,
+// sets rate limit response headers and reports whether the request is allowed.
+func (s *StubServer) checkRateLimit(c echo.Context, route *model.Route) bool {
+	key := c.RealIP()
+	if len(s.Config.RateLimitKey) > 0 {
+		if value := c.Request().Header.Get(s.Config.RateLimitKey); len(value) > 0 {
+			key = value // Clients without the header are identified by their IP address
+		}
+	}
+	now := time.Now()
+	bucket, allowed := s.rateLimiter.Allow(route, key, now)
+	reset := strconv.Itoa(int(math.Ceil(bucket.Reset.Sub(now).Seconds())))
+	headers := c.Response().Header()
+	headers.Set(rateLimitLimitHeader, strconv.Itoa(bucket.Limit))
+	headers.Set(rateLimitRemainingHeader, strconv.Itoa(bucket.Remaining()))
+	headers.Set(rateLimitResetHeader, reset)
+	if !allowed {
+		headers.Set(echo.HeaderRetryAfter, reset)
+	}
+	return allowed
 }
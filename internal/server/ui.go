@@ -0,0 +1,44 @@
+package server
+
+import (
+	"embed"
+	"github.com/labstack/echo/v4"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// uiFiles are the dashboard static files.
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// registerUIRoutes registers the dashboard routes on the given group. The dashboard
+// is a static page using management endpoints, so authentication is left to them.
+func (s *StubServer) registerUIRoutes(g *echo.Group) {
+	files, _ := fs.Sub(uiFiles, "ui")
+	index := echo.StaticFileHandler("index.html", files)
+	g.GET("", func(c echo.Context) error {
+		if uri := c.Request().RequestURI; strings.HasSuffix(uri, "/") {
+			// Relative URLs in the page require the path without trailing slash
+			return c.Redirect(http.StatusMovedPermanently, strings.TrimRight(uri, "/"))
+		}
+		return index(c)
+	})
+	g.GET("/*", echo.StaticDirectoryHandler(files, false))
+}
+
+// routeContentHandler returns the response body of the route
+// with the given stub file path or runtime route identifier.
+func (s *StubServer) routeContentHandler(c echo.Context) error {
+	filePath, id := c.QueryParam("file_path"), c.QueryParam("id")
+	for _, route := range s.getRoutes() {
+		if len(filePath) > 0 && route.FilePath == filePath || len(id) > 0 && route.ID == id {
+			if len(route.Content) == 0 {
+				return c.NoContent(http.StatusNoContent)
+			}
+			return c.Blob(http.StatusOK, route.ContentType, route.Content)
+		}
+	}
+	return echo.NewHTTPError(http.StatusNotFound, "route not found")
+}
@@ -0,0 +1,165 @@
+"use strict";
+
+// The dashboard only uses management endpoints (relative to the management prefix).
+const state = { token: sessionStorage.getItem("liege-token") || "", config: null, routes: [] };
+const $ = (id) => document.getElementById(id);
+
+// api sends a request to a management endpoint and returns the response.
+async function api(path, options = {}) {
+  const headers = Object.assign({}, options.headers);
+  if (state.token) {
+    headers["Authorization"] = "Bearer " + state.token;
+  }
+  const res = await fetch(path, Object.assign({}, options, { headers }));
+  if (res.status === 401) {
+    $("token-form").hidden = false;
+    throw new Error("authentication required");
+  } else if (!res.ok) {
+    const body = await res.json().catch(() => ({}));
+    throw new Error(body.message || res.status + " " + res.statusText);
+  }
+  return res;
+}
+
+// showStatus shows a status message.
+function showStatus(message) {
+  $("status").textContent = message;
+}
+
+// run runs an action and shows its error (if any).
+async function run(action) {
+  try {
+    await action();
+  } catch (err) {
+    showStatus("Error: " + err.message);
+  }
+}
+
+// cell creates a table cell with the given text.
+function cell(text) {
+  const td = document.createElement("td");
+  td.textContent = text;
+  return td;
+}
+
+// Configuration
+
+async function loadConfig() {
+  state.config = await (await api("config")).json();
+  $("config-root").value = state.config.root;
+  $("config-latency-min").value = state.config.latency.min;
+  $("config-latency-max").value = state.config.latency.max;
+}
+
+async function saveConfig(event) {
+  event.preventDefault();
+  const config = Object.assign({}, state.config, {
+    root: $("config-root").value,
+    latency: { min: Number($("config-latency-min").value), max: Number($("config-latency-max").value) },
+  });
+  await api("config", { method: "PUT", headers: { "Content-Type": "application/json" }, body: JSON.stringify(config) });
+  showStatus("Configuration saved");
+  await loadConfig();
+}
+
+async function refresh() {
+  const loadErrs = await (await api("refresh", { method: "POST" })).json();
+  showStatus(loadErrs.length ? "Stub files reloaded, " + loadErrs.length + " not loaded: " + loadErrs.join("; ")
+    : "Stub files reloaded");
+  await loadRoutes();
+}
+
+// Routes
+
+function routeSource(route) {
+  return route.file_path || "route " + route.id;
+}
+
+async function loadRoutes() {
+  state.routes = await (await api("routes")).json();
+  const methods = [...new Set(state.routes.map((r) => r.method).filter((m) => m))].sort();
+  const select = $("routes-method");
+  for (const method of methods) {
+    if (![...select.options].some((o) => o.value === method)) {
+      select.add(new Option(method, method));
+    }
+  }
+  renderRoutes();
+}
+
+function renderRoutes() {
+  const filter = $("routes-filter").value.toLowerCase();
+  const method = $("routes-method").value;
+  const routes = state.routes.filter((r) =>
+    (!filter || r.path.toLowerCase().includes(filter) || routeSource(r).toLowerCase().includes(filter)) &&
+    (!method || (method === "*" ? !r.method : r.method === method)));
+  const rows = routes.map((route) => {
+    const tr = document.createElement("tr");
+    const query = (route.query_params || []).map((q) => q.name + (q.value ? "=" + q.value : "")).join("&");
+    tr.append(cell(route.method || "*"), cell(route.path), cell(query), cell(route.code),
+      cell(route.content_type), cell(routeSource(route)));
+    tr.addEventListener("click", () => run(() => showContent(route, tr)));
+    return tr;
+  });
+  $("routes-table").replaceChildren(...rows);
+  $("routes-count").textContent = "(" + routes.length + "/" + state.routes.length + ")";
+}
+
+async function showContent(route, row) {
+  const query = route.file_path ? "file_path=" + encodeURIComponent(route.file_path) : "id=" + encodeURIComponent(route.id);
+  const res = await api("routes/content?" + query);
+  document.querySelectorAll("#routes-table tr.selected").forEach((tr) => tr.classList.remove("selected"));
+  row.classList.add("selected");
+  $("content-title").textContent = routeSource(route);
+  const type = res.headers.get("Content-Type") || "";
+  $("content-body").textContent = res.status === 204 ? "(empty)" :
+    /^(text\/|application\/(.+\+)?(json|xml|javascript))/.test(type) ? await res.text() : "(binary content: " + type + ")";
+  $("content").hidden = false;
+}
+
+// Requests
+
+async function loadRequests() {
+  const entries = await (await api("requests")).json();
+  const rows = entries.slice(-100).reverse().map((e) => {
+    const tr = document.createElement("tr");
+    tr.append(cell(new Date(e.timestamp).toLocaleTimeString()), cell(e.method), cell(e.url), cell(e.status),
+      cell(e.latency + " ms"), cell(e.file_path || (e.route_id ? "route " + e.route_id : "")));
+    return tr;
+  });
+  $("requests-table").replaceChildren(...rows);
+  $("requests-count").textContent = "(" + entries.length + ")";
+}
+
+async function clearRequests() {
+  await api("requests", { method: "DELETE" });
+  await loadRequests();
+}
+
+// Setup
+
+$("token").value = state.token;
+$("token-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  state.token = $("token").value;
+  sessionStorage.setItem("liege-token", state.token);
+  $("token-form").hidden = true;
+  showStatus("");
+  run(init);
+});
+$("config-form").addEventListener("submit", (event) => run(() => saveConfig(event)));
+$("refresh").addEventListener("click", () => run(refresh));
+$("routes-filter").addEventListener("input", renderRoutes);
+$("routes-method").addEventListener("change", renderRoutes);
+$("requests-clear").addEventListener("click", () => run(clearRequests));
+setInterval(() => {
+  if ($("requests-live").checked && $("token-form").hidden) {
+    run(loadRequests);
+  }
+}, 2000);
+
+async function init() {
+  await Promise.all([loadConfig(), loadRoutes(), loadRequests()]);
+}
+
+run(init);
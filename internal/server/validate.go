@@ -0,0 +1,52 @@
+package server
+
+import (
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+const (
+	// violationsHeader is the response header listing the request violations
+	// of the OpenAPI specification (in report mode).
+	violationsHeader = "X-Liege-Violations"
+	// problemContentType is the content type of problem responses.
+	problemContentType = "application/problem+json"
+)
+
+// problem is a problem details response body (RFC 9457) for an invalid request.
+type problem struct {
+	// Type is the problem type URI.
+	Type string `json:"type"`
+	// Title is the problem short summary.
+	Title string `json:"title"`
+	// Status is the response status code.
+	Status int `json:"status"`
+	// Violations are the request violations of the OpenAPI specification.
+	Violations []string `json:"violations"`
+}
+
+// validateRequest checks the request against the OpenAPI specification (if any)
+// and, depending on the validation mode, rejects it with a problem response
+// (returns false) or reports violations in a response header and the log.
+func (s *StubServer) validateRequest(c echo.Context) (bool, error) {
+	if s.spec == nil {
+		return true, nil
+	}
+	req := c.Request()
+	violations := s.spec.ValidateRequest(req, readBody(req))
+	if len(violations) == 0 {
+		return true, nil
+	}
+	console.Logger.Printf("Request %s %s violates the specification: %s\n",
+		req.Method, req.URL.RequestURI(), strings.Join(violations, "; "))
+	if s.Config.SpecMode == model.ReportViolations {
+		c.Response().Header().Set(violationsHeader, strings.Join(violations, "; "))
+		return true, nil
+	}
+	c.Response().Header().Set(echo.HeaderContentType, problemContentType)
+	return false, c.JSON(http.StatusBadRequest, problem{Type: "about:blank",
+		Title: "Request violates the specification", Status: http.StatusBadRequest, Violations: violations})
+}
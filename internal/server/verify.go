@@ -0,0 +1,70 @@
+package server
+
+import (
+	"gaelgirodon.fr/liege/internal/model"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sort"
+)
+
+// maxClosestRequests is the maximum number of closest non-matching requests
+// returned by a verification.
+const maxClosestRequests = 3
+
+// verification is the result of a verification.
+type verification struct {
+	// Matched indicates whether the expected number of requests matched the pattern.
+	Matched bool `json:"matched"`
+	// Count is the actual number of requests matching the pattern.
+	Count int `json:"count"`
+	// Indeterminate are the requests matching the pattern except for the body, which
+	// has been truncated in the journal (they may or may not match the pattern).
+	Indeterminate []journalEntry `json:"indeterminate"`
+	// Expected is a description of the expected number of requests.
+	Expected string `json:"expected"`
+	// Closest are the non-matching requests closest to the pattern.
+	Closest []closestRequest `json:"closest"`
+}
+
+// closestRequest is a journal entry not matching a pattern.
+type closestRequest struct {
+	journalEntry
+	// Mismatches are the reasons why the request doesn't match the pattern.
+	Mismatches []string `json:"mismatches"`
+}
+
+// verify counts journal entries matching the verification pattern and
+// returns the closest non-matching ones (with the fewest mismatches).
+// Entries with a truncated body can't be checked against a body matcher:
+// the expectation is met only if it is whatever their full body is.
+func (j *journal) verify(v *model.Verification) verification {
+	result := verification{Expected: v.Expected(), Indeterminate: []journalEntry{}, Closest: []closestRequest{}}
+	bodyless := v.Request
+	bodyless.Body = nil
+	for _, entry := range j.Entries(journalFilter{}) {
+		if entry.BodyTruncated && v.Request.Body != nil && len(bodyless.Mismatches(entry.Request)) == 0 {
+			result.Indeterminate = append(result.Indeterminate, entry)
+		} else if mismatches := v.Request.Mismatches(entry.Request); len(mismatches) == 0 {
+			result.Count++
+		} else {
+			result.Closest = append(result.Closest, closestRequest{entry, mismatches})
+		}
+	}
+	sort.SliceStable(result.Closest, func(i, j int) bool {
+		return len(result.Closest[i].Mismatches) < len(result.Closest[j].Mismatches)
+	})
+	result.Closest = result.Closest[:min(len(result.Closest), maxClosestRequests)]
+	result.Matched = v.Expects(result.Count) && v.Expects(result.Count+len(result.Indeterminate))
+	return result
+}
+
+// verifyHandler checks how many received requests match a pattern.
+func (s *StubServer) verifyHandler(c echo.Context) error {
+	v := new(model.Verification)
+	if err := c.Bind(v); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid body")
+	} else if err = v.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, s.journal.verify(v))
+}
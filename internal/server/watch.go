@@ -0,0 +1,193 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"gaelgirodon.fr/liege/internal/console"
+	"gaelgirodon.fr/liege/internal/model"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// watchDebounce is the delay without changes to wait for before reloading stub files.
+	watchDebounce = 300 * time.Millisecond
+	// watchPollInterval is the interval between two scans of the root directory when polling.
+	watchPollInterval = time.Second
+)
+
+// fileWatcher notifies changes of files under a directory.
+type fileWatcher struct {
+	// mu guards stop.
+	mu sync.Mutex
+	// stop is closed to stop watching the current directory.
+	stop chan struct{}
+	// changes receives a value when files have changed (buffered, values are coalesced).
+	changes chan struct{}
+}
+
+// Start starts watching files under the given directories (stopping to watch
+// the previous ones, if any) using inotify if available, mtimes polling otherwise.
+func (w *fileWatcher) Start(dirs []string, poll bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+	}
+	if w.changes == nil {
+		w.changes = make(chan struct{}, 1)
+	}
+	w.stop = make(chan struct{})
+	for _, dir := range dirs {
+		if !poll {
+			err := watchFiles(dir, w.stop, w.changes)
+			if err == nil {
+				console.Logger.Printf("Watching stub files in %s\n", dir)
+				continue
+			}
+			console.Logger.Printf("Unable to watch stub files with inotify (%s), falling back to polling\n", err)
+		}
+		console.Logger.Printf("Watching stub files in %s (polling every %s)\n", dir, watchPollInterval)
+		go pollFiles(dir, w.stop, w.changes)
+	}
+}
+
+// Changes returns the channel receiving a value when files have changed.
+func (w *fileWatcher) Changes() <-chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.changes
+}
+
+// notify notifies a change without blocking (pending changes are coalesced).
+func notify(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
+
+// fileState is the state of a file used to detect changes when polling.
+type fileState struct {
+	// modTime is the file modification time.
+	modTime time.Time
+	// size is the file size.
+	size int64
+}
+
+// scanFiles returns the state of all files under the given directory.
+func scanFiles(root string) map[string]fileState {
+	states := make(map[string]fileState)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			states[path] = fileState{info.ModTime(), info.Size()}
+		}
+		return nil
+	})
+	return states
+}
+
+// pollFiles scans files under the given directory periodically
+// and notifies changes until stopped.
+func pollFiles(root string, stop <-chan struct{}, changes chan<- struct{}) {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	states := scanFiles(root)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			current := scanFiles(root)
+			if len(current) != len(states) {
+				notify(changes)
+			} else {
+				for path, state := range current {
+					if previous, exists := states[path]; !exists || previous != state {
+						notify(changes)
+						break
+					}
+				}
+			}
+			states = current
+		}
+	}
+}
+
+// watch reloads stub files when they change (after a quiet period).
+func (s *StubServer) watch() {
+	config := s.config()
+	s.watcher.Start(config.RootDirs(), config.WatchPoll)
+	changes := s.watcher.Changes()
+	for range changes {
+		for debounce := time.NewTimer(watchDebounce); ; {
+			select {
+			case <-changes:
+				debounce.Reset(watchDebounce)
+				continue
+			case <-debounce.C:
+			}
+			break
+		}
+		s.reloadRoutes()
+	}
+}
+
+// reloadRoutes reloads stub files and logs a summary of route changes.
+func (s *StubServer) reloadRoutes() {
+	s.mu.RLock()
+	previous := s.fileRoutes
+	s.mu.RUnlock()
+	if err := s.loadRoutes(); err != nil {
+		console.Logger.Println("Error: unable to reload stub files: " + err.Error())
+		return
+	}
+	s.mu.RLock()
+	current := s.fileRoutes
+	s.mu.RUnlock()
+	added, removed, changed := diffRoutes(previous, current)
+	console.Logger.Printf("Stub files reloaded: %d route(s) added, %d removed, %d changed\n",
+		len(added), len(removed), len(changed))
+	for i, routes := range [][]string{added, removed, changed} {
+		for _, route := range routes {
+			console.Logger.Printf("  %c %s\n", "+-~"[i], route)
+		}
+	}
+}
+
+// routeKey identifies a route by the requests it matches and its stub file.
+func routeKey(r *model.Route) string {
+	return r.Signature() + " (" + r.FilePath + ")"
+}
+
+// diffRoutes compares two lists of routes and returns the keys of the routes
+// added, removed and changed (same key but different response), sorted.
+func diffRoutes(previous []*model.Route, current []*model.Route) (added, removed, changed []string) {
+	routes := make(map[string]*model.Route, len(previous))
+	for _, r := range previous {
+		routes[routeKey(r)] = r
+	}
+	for _, r := range current {
+		key := routeKey(r)
+		if p, exists := routes[key]; !exists {
+			added = append(added, key)
+		} else if p.Code != r.Code || p.ContentType != r.ContentType || !bytes.Equal(p.Content, r.Content) ||
+			p.Latency != r.Latency || p.RateLimit != r.RateLimit || fmt.Sprint(p.Headers) != fmt.Sprint(r.Headers) {
+			changed = append(changed, key)
+		}
+		delete(routes, key)
+	}
+	for key := range routes {
+		removed = append(removed, key)
+	}
+	for _, keys := range [][]string{added, removed, changed} {
+		sort.Strings(keys)
+	}
+	return
+}
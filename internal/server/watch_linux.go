@@ -0,0 +1,70 @@
+package server
+
+import (
+	"bytes"
+	"gaelgirodon.fr/liege/internal/console"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask is the mask of inotify events denoting a change of stub files.
+const inotifyMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// watchFiles watches files under the given directory (and its subdirectories)
+// using inotify and notifies changes until stopped.
+func watchFiles(root string, stop <-chan struct{}, changes chan<- struct{}) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+	file := os.NewFile(uintptr(fd), "inotify") // Non-blocking: reads are interrupted by Close
+	dirs := make(map[int32]string)
+	addDirs := func(dir string) error {
+		return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			wd, err := syscall.InotifyAddWatch(fd, path, inotifyMask)
+			if err != nil {
+				return err
+			}
+			dirs[int32(wd)] = path
+			return nil
+		})
+	}
+	if err = addDirs(root); err != nil {
+		_ = file.Close()
+		return err
+	}
+	go func() {
+		<-stop
+		_ = file.Close()
+	}()
+	go func() {
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return // Stopped
+			}
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+				offset += syscall.SizeofInotifyEvent + int(event.Len)
+				if event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+					// Watch new subdirectories
+					name := string(bytes.TrimRight(nameBytes, "\x00"))
+					if dir, exists := dirs[event.Wd]; exists && addDirs(filepath.Join(dir, name)) != nil {
+						console.Logger.Println("Error: unable to watch " + filepath.Join(dir, name))
+					}
+				}
+				notify(changes)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package server
+
+import "errors"
+
+// watchFiles returns an error as inotify is only available on Linux.
+func watchFiles(string, <-chan struct{}, chan<- struct{}) error {
+	return errors.New("inotify is not available on this platform")
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"gaelgirodon.fr/liege/internal/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_fileWatcher(t *testing.T) {
+	for _, poll := range []bool{false, true} {
+		root := t.TempDir()
+		var w fileWatcher
+		w.Start([]string{root}, poll)
+		_ = os.Mkdir(filepath.Join(root, "items"), 0755)
+		time.Sleep(watchPollInterval + 100*time.Millisecond) // Wait for the new directory to be watched
+		for _, change := range []func(){
+			func() { _ = os.WriteFile(filepath.Join(root, "items", "1.json"), []byte("{}"), 0644) },
+			func() { _ = os.Rename(filepath.Join(root, "items", "1.json"), filepath.Join(root, "items", "2.json")) },
+			func() { _ = os.Remove(filepath.Join(root, "items", "2.json")) },
+		} {
+			time.Sleep(200 * time.Millisecond) // Drain pending changes
+			select {
+			case <-w.Changes():
+			default:
+			}
+			change()
+			select {
+			case <-w.Changes():
+			case <-time.After(3 * watchPollInterval):
+				t.Errorf("want a change to be notified (poll = %v)", poll)
+			}
+		}
+		close(w.stop)
+	}
+}
+
+func Test_diffRoutes(t *testing.T) {
+	previous := []*model.Route{
+		{FilePath: "a.json", Path: "/a", Content: []byte("a")},
+		{FilePath: "b__GET.json", Path: "/b", Method: "GET", Content: []byte("b")},
+		{FilePath: "c.json", Path: "/c", QueryParams: []model.QueryParam{{Name: "q", Value: "1"}}},
+	}
+	current := []*model.Route{
+		{FilePath: "a.json", Path: "/a", Content: []byte("a2")},
+		{FilePath: "c.json", Path: "/c", QueryParams: []model.QueryParam{{Name: "q", Value: "1"}}},
+		{FilePath: "d.json", Path: "/d"},
+	}
+	added, removed, changed := diffRoutes(previous, current)
+	if !reflect.DeepEqual(added, []string{"* /d (d.json)"}) || !reflect.DeepEqual(removed, []string{"GET /b (b__GET.json)"}) ||
+		!reflect.DeepEqual(changed, []string{"* /a (a.json)"}) {
+		t.Errorf("want 1 route added, removed and changed, got %q %q %q", added, removed, changed)
+	}
+}
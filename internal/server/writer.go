@@ -0,0 +1,165 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"gaelgirodon.fr/liege/internal/model"
+	"mime"
+	"os"
+	paths "path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// pathSegmentPattern is the pattern to validate an URL path segment
+// to use as a file or directory name.
+var pathSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9._~@=,;+-]+$`)
+
+// preferredExtensions maps content types to the preferred file extension
+// when the MIME registry knows several extensions for a type.
+var preferredExtensions = map[string]string{
+	"application/javascript": ".js",
+	"application/json":       ".json",
+	"application/xml":        ".xml",
+	"application/yaml":       ".yaml",
+	"image/jpeg":             ".jpg",
+	"text/html":              ".html",
+	"text/javascript":        ".js",
+	"text/plain":             ".txt",
+	"text/xml":               ".xml",
+}
+
+// stubFile is a stub file to write into a root directory.
+type stubFile struct {
+	// relPath is the path to the stub file relative to the root directory.
+	relPath string
+	// content is the stub file content.
+	content []byte
+	// meta is the sidecar file content (nil if not needed).
+	meta []byte
+}
+
+// newStubFile builds the stub file for the given route, named
+// after the route using the file name syntax, with a sidecar
+// file for what the file name cannot express.
+func newStubFile(root string, route model.Route) (stubFile, error) {
+	if len(route.Method) > 0 && !methodOptPattern.MatchString(route.Method) {
+		return stubFile{}, errors.New("unsupported method " + route.Method)
+	}
+	dir, name, ext, err := stubLocation(route.Path)
+	if err != nil {
+		return stubFile{}, err
+	}
+	types, err := loadContentTypes(root)
+	if err != nil {
+		return stubFile{}, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(route.ContentType)
+	if len(ext) == 0 && len(route.Content) > 0 {
+		ext = extensionByType(mediaType, types)
+	}
+	queryParams := append([]model.QueryParam{}, route.QueryParams...)
+	sort.Slice(queryParams, func(i, j int) bool { return queryParams[i].Name < queryParams[j].Name })
+	route.QueryParams = queryParams
+	filename, extraParams := formatFileName(name, ext, route)
+	file := stubFile{relPath: filepath.Join(dir, filename), content: route.Content}
+	// Keep what the file name cannot express in a sidecar file
+	meta := sidecar{QueryParams: extraParams, Headers: route.Headers}
+	if typeByExt, _, _ := mime.ParseMediaType(types.byExtension(ext)); len(mediaType) > 0 && mediaType != typeByExt {
+		meta.ContentType = route.ContentType
+	}
+	if !meta.isEmpty() {
+		file.meta, _ = json.MarshalIndent(meta, "", "  ")
+	}
+	return file, nil
+}
+
+// StubPath returns the path (relative to the root directory)
+// to the stub file that WriteStub would write for the given route.
+func StubPath(root string, route model.Route) (string, error) {
+	file, err := newStubFile(root, route)
+	return file.relPath, err
+}
+
+// WriteStub writes a stub file (and a sidecar file if needed) into the root directory,
+// named after the route using the file name syntax, and returns the path to the file
+// relative to the root and whether the file has been written (false if the same stub
+// file already exists).
+func WriteStub(root string, route model.Route) (string, bool, error) {
+	file, err := newStubFile(root, route)
+	if err != nil {
+		return "", false, err
+	}
+	// Deduplicate identical stub files
+	path := filepath.Join(root, file.relPath)
+	if content, err := os.ReadFile(path); err == nil && bytes.Equal(content, file.content) {
+		if existing, _ := os.ReadFile(path + sidecarSuffix); bytes.Equal(existing, file.meta) {
+			return file.relPath, false, nil
+		}
+	}
+	// Write files
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", false, errors.New("unable to create directory for " + file.relPath)
+	}
+	if err = os.WriteFile(path, file.content, 0644); err != nil {
+		return "", false, errors.New("unable to write file " + file.relPath)
+	}
+	if len(file.meta) > 0 {
+		err = os.WriteFile(path+sidecarSuffix, file.meta, 0644)
+	} else if err = os.Remove(path + sidecarSuffix); errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		return "", false, errors.New("unable to write sidecar file for " + file.relPath)
+	}
+	return file.relPath, true, nil
+}
+
+// stubLocation returns the directory (relative to the root), the name
+// and the extension of the stub file to serve on the given URL path.
+func stubLocation(urlPath string) (dir, name, ext string, err error) {
+	segments := strings.Split(strings.Trim(paths.Clean("/"+urlPath), "/"), "/")
+	for _, segment := range segments {
+		if len(segment) > 0 && (!pathSegmentPattern.MatchString(segment) || strings.HasPrefix(segment, ".") ||
+			strings.Contains(segment, optsPrefix) || strings.HasSuffix(segment, optsSeparator)) {
+			return "", "", "", errors.New("unsupported path " + urlPath)
+		}
+	}
+	name = segments[len(segments)-1]
+	if len(name) == 0 {
+		name = "index"
+	}
+	ext = paths.Ext(name)
+	name = strings.TrimSuffix(name, ext)
+	return filepath.Join(segments[:len(segments)-1]...), name, ext, nil
+}
+
+// extensionByType returns the file extension to use for the given media type.
+func extensionByType(mediaType string, types contentTypes) string {
+	if len(mediaType) == 0 {
+		return ""
+	} else if ext, ok := preferredExtensions[mediaType]; ok {
+		return ext
+	}
+	var exts []string
+	for ext, contentType := range types {
+		if t, _, _ := mime.ParseMediaType(contentType); t == mediaType {
+			exts = append(exts, ext)
+		}
+	}
+	if len(exts) == 0 {
+		exts, _ = mime.ExtensionsByType(mediaType)
+	}
+	if len(exts) > 0 {
+		sort.Strings(exts)
+		return exts[0]
+	} else if strings.HasSuffix(mediaType, "+json") {
+		return ".json"
+	} else if strings.HasSuffix(mediaType, "+xml") {
+		return ".xml"
+	}
+	return ".bin"
+}
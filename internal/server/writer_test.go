@@ -0,0 +1,112 @@
+package server
+
+import (
+	"gaelgirodon.fr/liege/internal/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_formatFileName(t *testing.T) {
+	tests := []struct {
+		name            string
+		route           model.Route
+		ext             string
+		wantFilename    string
+		wantExtraParams []model.QueryParam
+	}{
+		{"none", model.NewRoute(), ".json", "test.json", nil},
+		{"method", model.Route{Method: "GET", Code: 200, Latency: model.Latency{Min: -1, Max: -1}}, "", "test__GET", nil},
+		{"query", model.Route{QueryParams: []model.QueryParam{{Name: "n"}, {Name: "s", Value: "v"}},
+			Code: 200, Latency: model.Latency{Min: -1, Max: -1}}, "", "test__qn_qs=v", nil},
+		{"query/extra", model.Route{QueryParams: []model.QueryParam{{Name: "n"}, {Name: "Page", Value: "A_B"}},
+			Code: 200, Latency: model.Latency{Min: -1, Max: -1}}, "", "test__qn", []model.QueryParam{{Name: "Page", Value: "A_B"}}},
+		{"all", model.Route{Method: "POST", QueryParams: []model.QueryParam{{Name: "n"}}, Code: 403,
+			Latency: model.Latency{Min: 10, Max: 20}}, ".txt", "test__POST_qn_403_l10-20.txt", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename, extraParams := formatFileName("test", test.ext, test.route)
+			if filename != test.wantFilename {
+				t.Errorf("want filename = %v, got %v", test.wantFilename, filename)
+			}
+			if !reflect.DeepEqual(extraParams, test.wantExtraParams) {
+				t.Errorf("want extra params = %v, got %v", test.wantExtraParams, extraParams)
+			}
+			// The file name must be parsed back to the same route
+			name, ext, route, err := parseFileName(filename, nil)
+			if err != nil || name != "test" || ext != test.ext || route.Method != test.route.Method ||
+				route.Code != test.route.Code || route.Latency != test.route.Latency ||
+				len(route.QueryParams)+len(extraParams) != len(test.route.QueryParams) {
+				t.Errorf("want %v to be parsed back, got %v %v %v %v", filename, name, ext, route, err)
+			}
+		})
+	}
+}
+
+func Test_stubLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantDir  string
+		wantName string
+		wantExt  string
+		wantErr  bool
+	}{
+		{"root", "/", "", "index", "", false},
+		{"file", "/items", "", "items", "", false},
+		{"dir", "/api/items/1", filepath.Join("api", "items"), "1", "", false},
+		{"ext", "/items/1.json", "items", "1", ".json", false},
+		{"clean", "/items/../users/", "", "users", "", false},
+		{"err/opts", "/items__GET", "", "", "", true},
+		{"err/separator", "/items_", "", "", "", true},
+		{"err/hidden", "/.git/config", "", "", "", true},
+		{"err/chars", "/a b", "", "", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, name, ext, err := stubLocation(test.urlPath)
+			if test.wantErr != (err != nil) {
+				t.Fatalf("want error = %v, got %v (%v)", test.wantErr, err != nil, err)
+			}
+			if dir != test.wantDir || name != test.wantName || ext != test.wantExt {
+				t.Errorf("want %q %q %q, got %q %q %q", test.wantDir, test.wantName, test.wantExt, dir, name, ext)
+			}
+		})
+	}
+}
+
+func TestWriteStub(t *testing.T) {
+	root := t.TempDir()
+	route := model.NewRoute()
+	route.Path = "/api/items"
+	route.Method = "GET"
+	route.QueryParams = []model.QueryParam{{Name: "sort", Value: "name"}, {Name: "Filter", Value: "x"}}
+	route.ContentType = "application/hal+json"
+	route.Content = []byte(`{"_links":{}}`)
+	route.Headers = map[string]string{"X-Total": "1"}
+	// Write the stub file and its sidecar file
+	relPath, written, err := WriteStub(root, route)
+	if err != nil || !written || relPath != filepath.Join("api", "items__GET_qsort=name.json") {
+		t.Fatalf("want stub file to be written, got %q %v %v", relPath, written, err)
+	}
+	// Deduplicate the identical stub
+	if _, written, err = WriteStub(root, route); err != nil || written {
+		t.Errorf("want identical stub file not to be written again, got %v %v", written, err)
+	}
+	// Build routes back from the stub file
+	routes, err := BuildRoutes(root, false)
+	if err != nil || len(routes) != 2 {
+		t.Fatalf("want 2 routes, got %d (%v)", len(routes), err)
+	}
+	r := routes[0]
+	if r.Path != "/api/items" || r.Method != "GET" || len(r.QueryParams) != 2 ||
+		r.ContentType != "application/hal+json" || r.Headers["X-Total"] != "1" ||
+		string(r.Content) != string(route.Content) {
+		t.Errorf("want route to be built back from the stub file, got %+v", r)
+	}
+	if _, err := os.Stat(filepath.Join(root, relPath+sidecarSuffix)); err != nil {
+		t.Errorf("want sidecar file to be written, got %v", err)
+	}
+}
@@ -20,20 +20,34 @@ const (
 // Test_e2e tests the application end-to-end
 // (by sending requests to the server).
 func Test_e2e(t *testing.T) {
-	// Start the server asynchronously
-	s := &server.StubServer{Config: model.Config{Root: root, Port: port}}
-	go func() {
-		_ = s.Start()
-	}()
-	// Wait for the server to be up
-	err := errors.New("wait")
-	for i := 0; err != nil && i < 10; i++ {
-		time.Sleep(time.Second)
-		_, err = net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", port), time.Second)
-	}
+	// Start the server
+	startServer(&server.StubServer{Config: model.Config{Root: root, Port: port}})
 
 	// Test stub routes
 	testStub(t)
+	testRateLimitedStub(t)
 	// Test management endpoints
 	testManagementEndpoints(t)
+	// Test routes created at runtime
+	testRuntimeRoutes(t)
+	// Test forwarding to upstream servers
+	testProxy(t)
+	// Test request validation against an OpenAPI specification
+	testSpecValidation(t)
+	// Test management endpoints protection
+	testManagementProtection(t)
+}
+
+// startServer starts a stub server asynchronously and waits for it to be up.
+func startServer(s *server.StubServer) {
+	go func() {
+		_ = s.Start()
+	}()
+	for _, p := range []uint16{s.Config.Port, s.Config.MgmtPort} {
+		err := errors.New("wait")
+		for i := 0; p > 0 && err != nil && i < 10; i++ {
+			time.Sleep(time.Second)
+			_, err = net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", p), time.Second)
+		}
+	}
 }
@@ -3,10 +3,13 @@ package test
 import (
 	"encoding/json"
 	"fmt"
+	"gaelgirodon.fr/liege/internal/command"
 	"gaelgirodon.fr/liege/internal/model"
+	"gaelgirodon.fr/liege/internal/openapi"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -26,6 +29,7 @@ func testManagementEndpoints(t *testing.T) {
 		{"bind", "???"},
 		{"root", `{"root":"notfound","latency":{"min":1,"max":2}}`},
 		{"latency", `{"root":"` + root + `","latency":{"min":3,"max":2}}`},
+		{"upstream", `{"root":"` + root + `","latency":{"min":1,"max":2},"upstreams":[{"prefix":"/","url":"nowhere"}]}`},
 	}
 	for _, test := range putConfigBadRequestTests {
 		t.Run("e2e/mngmt/config/put/400/"+test.name, func(t *testing.T) {
@@ -52,21 +56,437 @@ func testManagementEndpoints(t *testing.T) {
 		checkConfigEndpoint(t, model.Config{Root: root, Latency: model.Latency{Min: 1, Max: 2}})
 	})
 
+	// GET /_liege/health & /_liege/ready => check the server is up and ready
+	t.Run("e2e/mngmt/probes/get", func(t *testing.T) {
+		for _, path := range []string{"/_liege/health", "/_liege/ready"} {
+			res, _ := http.Get(fmt.Sprintf("http://localhost:%d%s", port, path))
+			_ = res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Errorf("want %s status = %d, got %d", path, http.StatusOK, res.StatusCode)
+			}
+		}
+		healthcheck, _ := command.Find("healthcheck")
+		if err := healthcheck.Run([]string{"-p", fmt.Sprint(port)}); err != nil {
+			t.Errorf("want healthcheck to succeed, got %v", err)
+		}
+		if err := healthcheck.Run([]string{"-p", "1", "-probe", "health"}); err == nil {
+			t.Error("want healthcheck to fail on a closed port")
+		}
+		dir := t.TempDir()
+		configFile := filepath.Join(dir, "liege.json")
+		_ = os.WriteFile(configFile, []byte(fmt.Sprintf(`{"port":%d}`, port)), 0644)
+		if err := healthcheck.Run([]string{dir}); err != nil {
+			t.Errorf("want healthcheck to read the port from the configuration file, got %v", err)
+		}
+		_ = os.WriteFile(configFile, []byte(fmt.Sprintf(`{"port":%d,"mgmt_prefix":"/_other"}`, port)), 0644)
+		if err := healthcheck.Run([]string{"-f", configFile}); err == nil {
+			t.Error("want healthcheck to probe the management prefix from the configuration file")
+		}
+	})
+
 	// GET /_liege/routes => get and check routes
 	t.Run("e2e/mngmt/routes/get", func(t *testing.T) {
-		checkRoutesEndpoint(t, 12)
+		checkRoutesEndpoint(t, 16)
+	})
+
+	// GET /_liege/routes/content => get the content of a route
+	t.Run("e2e/mngmt/routes/content", func(t *testing.T) {
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/_liege/routes/content?file_path=items/1__GET.json", port))
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		want, _ := os.ReadFile("data/items/1__GET.json")
+		if res.StatusCode != http.StatusOK || string(body) != string(want) {
+			t.Errorf("want status = %d with the stub file content, got %d '%s'", http.StatusOK, res.StatusCode, body)
+		}
+		res, _ = http.Get(fmt.Sprintf("http://localhost:%d/_liege/routes/content?file_path=unknown", port))
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusNotFound {
+			t.Errorf("want status = %d, got %d", http.StatusNotFound, res.StatusCode)
+		}
+	})
+
+	// GET /_liege/ui => get the dashboard
+	t.Run("e2e/mngmt/ui/get", func(t *testing.T) {
+		for path, wantType := range map[string]string{"/_liege/ui": "text/html", "/_liege/ui/app.js": "javascript"} {
+			res, _ := http.Get(fmt.Sprintf("http://localhost:%d%s", port, path))
+			_ = res.Body.Close()
+			if res.StatusCode != http.StatusOK || !strings.Contains(res.Header.Get("Content-Type"), wantType) {
+				t.Errorf("want %s to be served as %s, got %d %s", path, wantType, res.StatusCode, res.Header.Get("Content-Type"))
+			}
+		}
+	})
+
+	// GET /_liege/openapi.json => get routes as an OpenAPI document
+	t.Run("e2e/mngmt/openapi/get", func(t *testing.T) {
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/_liege/openapi.json", port))
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("want status = %d, got %d", http.StatusOK, res.StatusCode)
+		}
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		var doc openapi.Document
+		_ = json.Unmarshal(body, &doc)
+		if _, found := doc.Paths["/items/index.json"]; found || doc.Paths["/items"] == nil {
+			t.Fatalf("want aliases to be merged into /items, got %s", body)
+		}
+		if op := doc.Paths["/items"].Get; op == nil || len(op.Parameters) != 1 || op.Parameters[0].Required {
+			t.Errorf("want GET /items with an optional query parameter, got %+v", op)
+		}
+		if item := doc.Paths["/items/1"]; item == nil || item.Get == nil || item.Post != nil {
+			t.Errorf("want GET /items/1 only, got %+v", item)
+		}
 	})
 
 	// POST /_liege/refresh => modify & reload stub files and check routes
 	t.Run("e2e/mngmt/refresh/post", func(t *testing.T) {
 		_ = os.WriteFile("data/test", []byte(""), 0666)
 		res, _ := http.Post(fmt.Sprintf("http://localhost:%d/_liege/refresh", port), "", http.NoBody)
-		if res.StatusCode != http.StatusNoContent {
-			t.Errorf("want status = %d, got %v", http.StatusNoContent, res.StatusCode)
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("want status = %d, got %v", http.StatusOK, res.StatusCode)
 		}
-		checkRoutesEndpoint(t, 13)
+		checkRoutesEndpoint(t, 17)
 		_ = os.Remove("data/test")
 	})
+
+	// GET /_liege/ready => not ready while the refresh fails
+	t.Run("e2e/mngmt/ready/refresh-failed", func(t *testing.T) {
+		_ = os.WriteFile("data/liege.mime.json", []byte("{"), 0666)
+		res, _ := http.Post(fmt.Sprintf("http://localhost:%d/_liege/refresh", port), "", http.NoBody)
+		_ = res.Body.Close()
+		_ = os.Remove("data/liege.mime.json")
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("want refresh status = %d, got %d", http.StatusBadRequest, res.StatusCode)
+		}
+		res, _ = http.Get(fmt.Sprintf("http://localhost:%d/_liege/ready", port))
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("want ready status = %d, got %d", http.StatusServiceUnavailable, res.StatusCode)
+		}
+		res, _ = http.Post(fmt.Sprintf("http://localhost:%d/_liege/refresh", port), "", http.NoBody)
+		_ = res.Body.Close()
+		res, _ = http.Get(fmt.Sprintf("http://localhost:%d/_liege/ready", port))
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("want ready status = %d after a successful refresh, got %d", http.StatusOK, res.StatusCode)
+		}
+	})
+
+	// GET /_liege/errors => list stub files that could not be loaded during the last refresh
+	t.Run("e2e/mngmt/errors/get", func(t *testing.T) {
+		refresh := func() []string {
+			res, _ := http.Post(fmt.Sprintf("http://localhost:%d/_liege/refresh", port), "", http.NoBody)
+			var loadErrs []string
+			_ = json.NewDecoder(res.Body).Decode(&loadErrs)
+			_ = res.Body.Close()
+			return loadErrs
+		}
+		_ = os.WriteFile("data/users__GTE.json", []byte("[]"), 0666)
+		refreshErrs := refresh()
+		_ = os.Remove("data/users__GTE.json")
+		if len(refreshErrs) != 1 || !strings.Contains(refreshErrs[0], "'GTE'") {
+			t.Errorf("want the load error in the refresh response, got %v", refreshErrs)
+		}
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/_liege/errors", port))
+		var loadErrs []string
+		_ = json.NewDecoder(res.Body).Decode(&loadErrs)
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK || len(loadErrs) != 1 || !strings.Contains(loadErrs[0], "'GTE'") {
+			t.Errorf("want status = %d with the load error, got %d (%v)", http.StatusOK, res.StatusCode, loadErrs)
+		}
+		if refreshErrs = refresh(); refreshErrs == nil || len(refreshErrs) != 0 {
+			t.Errorf("want no load errors in the refresh response, got %v", refreshErrs)
+		}
+	})
+
+	// POST /_liege/echo => get the request back as JSON
+	t.Run("e2e/mngmt/echo/post", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost:%d/_liege/echo?a=1", port),
+			strings.NewReader(`{"id":1}`))
+		req.Header.Set("X-Test", "echo")
+		res, _ := http.DefaultClient.Do(req)
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("want status = %d, got %d", http.StatusOK, res.StatusCode)
+		}
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		var echoed model.Request
+		_ = json.Unmarshal(body, &echoed)
+		if echoed.Method != http.MethodPost || echoed.Path != "/_liege/echo" || echoed.Query.Get("a") != "1" ||
+			echoed.Headers.Get("X-Test") != "echo" || echoed.Body != `{"id":1}` || len(echoed.RemoteAddr) == 0 {
+			t.Errorf("want the request to be echoed, got %s", body)
+		}
+	})
+
+	// DELETE /_liege/requests => clear the request journal
+	t.Run("e2e/mngmt/requests/delete", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:%d/_liege/requests", port), http.NoBody)
+		res, _ := http.DefaultClient.Do(req)
+		if res.StatusCode != http.StatusNoContent {
+			t.Errorf("want status = %d, got %d", http.StatusNoContent, res.StatusCode)
+		}
+		checkRequestsEndpoint(t, "", 0)
+	})
+
+	// GET /_liege/requests => get and filter the request journal
+	t.Run("e2e/mngmt/requests/get", func(t *testing.T) {
+		for _, path := range []string{"/items/1", "/items/2", "/admin"} {
+			res, _ := http.Get(fmt.Sprintf("http://localhost:%d%s", port, path))
+			_ = res.Body.Close()
+		}
+		checkRequestsEndpoint(t, "", 3)
+		checkRequestsEndpoint(t, "?status=404", 1)
+		entries := checkRequestsEndpoint(t, "?path=/items/*&method=GET&status=200", 1)
+		if len(entries) == 1 && (entries[0]["file_path"] != "items/1__GET.json" || entries[0]["url"] != "/items/1") {
+			t.Errorf("want the matched route to be journaled, got %v", entries[0])
+		}
+		entries = checkRequestsEndpoint(t, "?path=/admin", 1)
+		if len(entries) == 1 && (entries[0]["status"] != 403.0 || entries[0]["latency"] != 50.0) {
+			t.Errorf("want the status and latency to be journaled, got %v", entries[0])
+		}
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/_liege/requests?since=yesterday", port))
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("want status = %d, got %d", http.StatusBadRequest, res.StatusCode)
+		}
+	})
+
+	// POST /_liege/verify => verify received requests
+	t.Run("e2e/mngmt/verify/post", func(t *testing.T) {
+		tests := []struct {
+			body        string
+			wantStatus  int
+			wantMatched bool
+			wantCount   int
+			wantClosest string
+		}{
+			{`{"request":{"method":"GET","path":"/items/1"},"count":1}`, http.StatusOK, true, 1, ""},
+			{`{"request":{"path":"/items/2"},"min":2}`, http.StatusOK, false, 1, ""},
+			{`{"request":{"method":"POST","path":"/items/1"}}`, http.StatusOK, false, 0, "method GET is not POST"},
+			{`{"request":{},"min":2,"max":1}`, http.StatusBadRequest, false, 0, ""},
+		}
+		for _, test := range tests {
+			res, _ := http.Post(fmt.Sprintf("http://localhost:%d/_liege/verify", port), "application/json",
+				strings.NewReader(test.body))
+			body, _ := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			if res.StatusCode != test.wantStatus {
+				t.Errorf("want status = %d, got %d", test.wantStatus, res.StatusCode)
+				continue
+			} else if res.StatusCode != http.StatusOK {
+				continue
+			}
+			var result struct {
+				Matched bool
+				Count   int
+				Closest []struct{ Mismatches []string }
+			}
+			_ = json.Unmarshal(body, &result)
+			if result.Matched != test.wantMatched || result.Count != test.wantCount {
+				t.Errorf("want matched = %v and count = %d for %s, got %s", test.wantMatched, test.wantCount, test.body, body)
+			}
+			if len(test.wantClosest) > 0 && (len(result.Closest) == 0 || len(result.Closest[0].Mismatches) != 1 ||
+				result.Closest[0].Mismatches[0] != test.wantClosest) {
+				t.Errorf("want closest request mismatch '%s', got %s", test.wantClosest, body)
+			}
+		}
+	})
+
+	// POST /_liege/explain => explain why a request matches routes or not
+	t.Run("e2e/mngmt/explain/post", func(t *testing.T) {
+		tests := []struct {
+			body       string
+			wantStatus int
+			wantRoute  string
+			wantCode   int
+		}{
+			{`{"url":"/items?s=1"}`, http.StatusOK, "items/index__qs.json", http.StatusOK},
+			{`{"url":"/items"}`, http.StatusOK, "items/index.json", http.StatusOK},
+			{`{"method":"POST","url":"/unknown"}`, http.StatusOK, "", http.StatusNotFound},
+			{`{"url":"items"}`, http.StatusBadRequest, "", 0},
+		}
+		for _, test := range tests {
+			res, _ := http.Post(fmt.Sprintf("http://localhost:%d/_liege/explain", port), "application/json",
+				strings.NewReader(test.body))
+			body, _ := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			if res.StatusCode != test.wantStatus {
+				t.Errorf("want status = %d, got %d", test.wantStatus, res.StatusCode)
+				continue
+			} else if res.StatusCode != http.StatusOK {
+				continue
+			}
+			var result struct {
+				Candidates []struct {
+					FilePath string `json:"file_path"`
+					Matched  bool
+					Checks   []struct{ Passed bool }
+				}
+				Route *struct {
+					FilePath string `json:"file_path"`
+				}
+				Status int
+			}
+			_ = json.Unmarshal(body, &result)
+			route := ""
+			if result.Route != nil {
+				route = result.Route.FilePath
+			}
+			if !strings.HasSuffix(route, test.wantRoute) || (len(test.wantRoute) == 0) != (len(route) == 0) || result.Status != test.wantCode || len(result.Candidates) == 0 {
+				t.Errorf("want route '%s' and status %d for %s, got %s", test.wantRoute, test.wantCode, test.body, body)
+			}
+		}
+	})
+
+	// PUT /_liege/config => enable diagnostics on unmatched requests
+	t.Run("e2e/mngmt/diagnostics", func(t *testing.T) {
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/items/1.xml", port))
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusNotFound || len(body) > 0 {
+			t.Errorf("want status = %d without body by default, got %d (%s)", http.StatusNotFound, res.StatusCode, body)
+		}
+		setDiagnostics := func(enabled bool) {
+			req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("http://localhost:%d/_liege/config", port),
+				strings.NewReader(fmt.Sprintf(`{"root":"%s","latency":{"min":1,"max":2},"diagnostics":%v}`, root, enabled)))
+			req.Header.Set("Content-Type", "application/json")
+			res, _ := http.DefaultClient.Do(req)
+			_ = res.Body.Close()
+		}
+		setDiagnostics(true)
+		defer setDiagnostics(false)
+		res, _ = http.Get(fmt.Sprintf("http://localhost:%d/items/1.xml", port))
+		body, _ = io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		var d struct {
+			Closest []struct{ Path string }
+			Hints   []string
+		}
+		_ = json.Unmarshal(body, &d)
+		if res.StatusCode != http.StatusNotFound || len(d.Closest) == 0 || len(d.Hints) == 0 ||
+			!strings.Contains(strings.Join(d.Hints, "\n"), "try /items/1 ") {
+			t.Errorf("want status = %d with closest routes and hints, got %d (%s)", http.StatusNotFound, res.StatusCode, body)
+		}
+	})
+
+	// PUT /_liege/config => switch layers
+	t.Run("e2e/mngmt/config/put/layers", func(t *testing.T) {
+		setLayers := func(layers string) int {
+			req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("http://localhost:%d/_liege/config", port),
+				strings.NewReader(fmt.Sprintf(`{"root":"%s","latency":{"min":1,"max":2},"layers":%s}`, root, layers)))
+			req.Header.Set("Content-Type", "application/json")
+			res, _ := http.DefaultClient.Do(req)
+			_ = res.Body.Close()
+			return res.StatusCode
+		}
+		if status := setLayers(`["layers/unknown"]`); status != http.StatusBadRequest {
+			t.Errorf("want status = %d for an unknown layer, got %d", http.StatusBadRequest, status)
+		}
+		if status := setLayers(`["layers/degraded"]`); status != http.StatusNoContent {
+			t.Errorf("want status = %d, got %d", http.StatusNoContent, status)
+		}
+		for path, wantStatus := range map[string]int{"/items": http.StatusServiceUnavailable,
+			"/items/1": http.StatusNotFound, "/admin": http.StatusForbidden} {
+			if res, _ := http.Get(fmt.Sprintf("http://localhost:%d%s", port, path)); res.StatusCode != wantStatus {
+				t.Errorf("want status = %d for %s with the layer, got %d", wantStatus, path, res.StatusCode)
+			}
+		}
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/_liege/routes", port))
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if !strings.Contains(string(body), `"layer":"layers/degraded"`) {
+			t.Errorf("want routes to show their layer, got %s", body)
+		}
+		if status := setLayers(`[]`); status != http.StatusNoContent {
+			t.Errorf("want status = %d, got %d", http.StatusNoContent, status)
+		}
+		if res, _ := http.Get(fmt.Sprintf("http://localhost:%d/items/1", port)); res.StatusCode != http.StatusOK {
+			t.Errorf("want status = %d without layers, got %d", http.StatusOK, res.StatusCode)
+		}
+	})
+
+	// GET /items with X-Liege-Profile => match profile stubs first, then base stubs
+	t.Run("e2e/mngmt/profiles", func(t *testing.T) {
+		for path, wantStatus := range map[string]int{"/items": http.StatusServiceUnavailable, "/items/1": http.StatusOK} {
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d%s", port, path), nil)
+			req.Header.Set("X-Liege-Profile", "outage")
+			if res, _ := http.DefaultClient.Do(req); res.StatusCode != wantStatus {
+				t.Errorf("want status = %d for %s with the profile, got %d", wantStatus, path, res.StatusCode)
+			}
+		}
+		if res, _ := http.Get(fmt.Sprintf("http://localhost:%d/items", port)); res.StatusCode != http.StatusOK {
+			t.Errorf("want status = %d without the profile, got %d", http.StatusOK, res.StatusCode)
+		}
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/_liege/routes?profile=outage", port))
+		var routes []model.Route
+		_ = json.NewDecoder(res.Body).Decode(&routes)
+		_ = res.Body.Close()
+		if len(routes) != 16 || routes[0].Profile != "outage" || routes[3].Profile != "" {
+			t.Errorf("want profile routes first, then base routes, got %+v", routes)
+		}
+	})
+
+	// GET /_liege/metrics => get metrics in the Prometheus format
+	t.Run("e2e/mngmt/metrics/get", func(t *testing.T) {
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/_liege/metrics", port))
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/plain") {
+			t.Errorf("want status = %d with text content, got %d", http.StatusOK, res.StatusCode)
+		}
+		for _, want := range []string{`liege_requests_total{route="items/1__GET.json",method="GET",status="200"}`,
+			"liege_unmatched_requests_total ", "liege_routes 16\n", "liege_load_errors 0\n"} {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("want metrics to contain %q, got:\n%s", want, body)
+			}
+		}
+	})
+
+	// GET /_liege/ratelimits => get rate limit buckets state
+	t.Run("e2e/mngmt/ratelimits/get", func(t *testing.T) {
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/_liege/ratelimits", port))
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("want status = %d, got %d", http.StatusOK, res.StatusCode)
+		}
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		var buckets []map[string]any
+		_ = json.Unmarshal(body, &buckets)
+		if len(buckets) != 1 || buckets[0]["file_path"] != "limited__rl2m" || buckets[0]["count"] != 2.0 {
+			t.Errorf("want a single full bucket for limited__rl2m, got %s", body)
+		}
+	})
+
+	// DELETE /_liege/ratelimits => reset rate limit buckets
+	t.Run("e2e/mngmt/ratelimits/delete", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:%d/_liege/ratelimits", port), http.NoBody)
+		res, _ := http.DefaultClient.Do(req)
+		if res.StatusCode != http.StatusNoContent {
+			t.Errorf("want status = %d, got %d", http.StatusNoContent, res.StatusCode)
+		}
+		res, _ = http.Get(fmt.Sprintf("http://localhost:%d/limited", port))
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("want status = %d after reset, got %d", http.StatusOK, res.StatusCode)
+		}
+	})
+}
+
+// checkRequestsEndpoint requests the /_liege/requests endpoint with the given
+// query string, checks the entries count and returns the entries.
+func checkRequestsEndpoint(t *testing.T, query string, wantCount int) []map[string]any {
+	res, _ := http.Get(fmt.Sprintf("http://localhost:%d/_liege/requests%s", port, query))
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("want status = %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	var entries []map[string]any
+	_ = json.Unmarshal(body, &entries)
+	if len(entries) != wantCount {
+		t.Errorf("want %d entries for '%s', got %d", wantCount, query, len(entries))
+	}
+	return entries
 }
 
 // checkConfigEndpoint requests the /_liege/config endpoint
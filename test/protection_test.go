@@ -0,0 +1,51 @@
+package test
+
+import (
+	"fmt"
+	"gaelgirodon.fr/liege/internal/model"
+	"gaelgirodon.fr/liege/internal/server"
+	"net/http"
+	"testing"
+)
+
+// testManagementProtection tests management endpoints authentication,
+// custom prefix and separate listener.
+func testManagementProtection(t *testing.T) {
+	startServer(&server.StubServer{Config: model.Config{Root: root, Port: port + 3, MgmtPrefix: "/_admin",
+		MgmtToken: "secret", MgmtAuth: "admin:pass"}})
+	startServer(&server.StubServer{Config: model.Config{Root: root, Port: port + 4, MgmtPort: port + 5,
+		MgmtLoopback: true}})
+	tests := []struct {
+		name       string
+		port       int
+		path       string
+		auth       func(req *http.Request)
+		wantStatus int
+	}{
+		{"e2e/mngmt/auth/none", port + 3, "/_admin/config", func(*http.Request) {}, http.StatusUnauthorized},
+		{"e2e/mngmt/auth/token", port + 3, "/_admin/config",
+			func(req *http.Request) { req.Header.Set("Authorization", "Bearer secret") }, http.StatusOK},
+		{"e2e/mngmt/auth/bad-token", port + 3, "/_admin/config",
+			func(req *http.Request) { req.Header.Set("Authorization", "Bearer bad") }, http.StatusUnauthorized},
+		{"e2e/mngmt/auth/basic", port + 3, "/_admin/config",
+			func(req *http.Request) { req.SetBasicAuth("admin", "pass") }, http.StatusOK},
+		{"e2e/mngmt/prefix/stub", port + 3, "/_liege/config", func(*http.Request) {}, http.StatusNotFound},
+		{"e2e/mngmt/separate/stubs", port + 4, "/_liege/config", func(*http.Request) {}, http.StatusNotFound},
+		{"e2e/mngmt/separate/mngmt", port + 5, "/_liege/config", func(*http.Request) {}, http.StatusOK},
+		{"e2e/mngmt/separate/no-stubs", port + 5, "/items/1", func(*http.Request) {}, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d%s", test.port, test.path), http.NoBody)
+			test.auth(req)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("want no error, got %v", err)
+			}
+			_ = res.Body.Close()
+			if res.StatusCode != test.wantStatus {
+				t.Errorf("want status = %d, got %d", test.wantStatus, res.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProxy tests forwarding unmatched requests to upstream servers.
+func testProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream-Method", r.Method)
+		_, _ = fmt.Fprintf(w, "%s %s", r.URL.RequestURI(), body)
+	}))
+	defer upstream.Close()
+	tests := []struct {
+		name       string
+		upstreams  string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"e2e/proxy/stub", `[{"prefix":"/","url":"` + upstream.URL + `"}]`,
+			http.MethodGet, "/items/1", http.StatusOK, "{}"},
+		{"e2e/proxy/default", `[{"prefix":"/","url":"` + upstream.URL + `"}]`,
+			http.MethodPost, "/unknown?a=1", http.StatusOK, "/unknown?a=1 body"},
+		{"e2e/proxy/prefix/match", `[{"prefix":"/api","url":"` + upstream.URL + `/base"}]`,
+			http.MethodPut, "/api/items", http.StatusOK, "/base/api/items body"},
+		{"e2e/proxy/prefix/no-match", `[{"prefix":"/api","url":"` + upstream.URL + `"}]`,
+			http.MethodGet, "/other", http.StatusNotFound, ""},
+		{"e2e/proxy/bad-gateway", `[{"prefix":"/","url":"http://localhost:1"}]`,
+			http.MethodGet, "/unknown", http.StatusBadGateway, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			updateConfig(t, fmt.Sprintf(`{"root":"%s","latency":{"min":0,"max":0},"upstreams":%s}`, root, test.upstreams))
+			req, _ := http.NewRequest(test.method, fmt.Sprintf("http://localhost:%d%s", port, test.path),
+				strings.NewReader("body"))
+			res, _ := http.DefaultClient.Do(req)
+			body, _ := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			if res.StatusCode != test.wantStatus {
+				t.Errorf("want status = %d, got %d", test.wantStatus, res.StatusCode)
+			}
+			if len(test.wantBody) > 0 && strings.TrimSpace(string(body)) != test.wantBody {
+				t.Errorf("want body = %q, got %q", test.wantBody, body)
+			}
+			proxied := res.Header.Get("X-Liege-Proxied")
+			if wantProxied := test.wantStatus != http.StatusNotFound && test.wantBody != "{}"; wantProxied != (len(proxied) > 0) {
+				t.Errorf("want X-Liege-Proxied to be set = %v, got %q", wantProxied, proxied)
+			}
+		})
+	}
+
+	// Record mode => write the proxied exchange as a stub file and serve it after a refresh
+	t.Run("e2e/proxy/record", func(t *testing.T) {
+		defer func() { _ = os.RemoveAll(filepath.Join(root, "recorded")) }()
+		updateConfig(t, fmt.Sprintf(`{"root":"%s","latency":{"min":0,"max":0},"record":true,"upstreams":[{"prefix":"/","url":"%s"}]}`,
+			root, upstream.URL))
+		// getItem gets the recorded item and reports whether the request was proxied
+		getItem := func(i int) bool {
+			res, _ := http.Get(fmt.Sprintf("http://localhost:%d/recorded/item?id=1", port))
+			body, _ := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			if res.StatusCode != http.StatusOK || string(body) != "/recorded/item?id=1 " {
+				t.Errorf("want recorded response #%d, got %d %q", i, res.StatusCode, body)
+			}
+			if method := res.Header.Get("X-Upstream-Method"); method != http.MethodGet {
+				t.Errorf("want recorded X-Upstream-Method header on request #%d, got %q", i, method)
+			}
+			return len(res.Header.Get("X-Liege-Proxied")) > 0
+		}
+		if !getItem(1) {
+			t.Errorf("want request #1 to be proxied")
+		}
+		proxied := true
+		for i := 2; proxied && i < 20; i++ { // Routes are refreshed in the background
+			time.Sleep(50 * time.Millisecond)
+			proxied = getItem(i)
+		}
+		if proxied {
+			t.Errorf("want the recorded stub to be served")
+		}
+		if _, err := os.Stat(filepath.Join(root, "recorded", "item__GET_qid=1.txt")); err != nil {
+			t.Errorf("want stub file to be recorded, got %v", err)
+		}
+		// Repeated query parameters cannot be matched by a route
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/recorded/tags?tag=a&tag=b", port))
+		_ = res.Body.Close()
+		if matches, _ := filepath.Glob(filepath.Join(root, "recorded", "tags*")); len(matches) > 0 {
+			t.Errorf("want no stub file recorded for a repeated query parameter, got %v", matches)
+		}
+	})
+
+	updateConfig(t, fmt.Sprintf(`{"root":"%s","latency":{"min":0,"max":0}}`, root))
+	res, _ := http.Post(fmt.Sprintf("http://localhost:%d/_liege/refresh", port), "", http.NoBody)
+	_ = res.Body.Close()
+}
+
+// updateConfig updates the stub server configuration.
+func updateConfig(t *testing.T, body string) {
+	req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("http://localhost:%d/_liege/config", port),
+		strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	res, _ := http.DefaultClient.Do(req)
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatalf("want config update status = %d, got %d", http.StatusNoContent, res.StatusCode)
+	}
+}
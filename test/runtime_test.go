@@ -0,0 +1,104 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRuntimeRoutes tests creating, updating and deleting routes at runtime.
+func testRuntimeRoutes(t *testing.T) {
+	t.Run("e2e/runtime/create", func(t *testing.T) {
+		res := sendRouteRequest(http.MethodPost, "/_liege/routes",
+			`{"id":"hello","path":"/runtime/hello","method":"GET","code":201,"body":"{\"hello\":true}"}`)
+		if res.StatusCode != http.StatusCreated {
+			t.Errorf("want status = %d, got %d", http.StatusCreated, res.StatusCode)
+		}
+		checkRuntimeRoute(t, "/runtime/hello", http.StatusCreated, `{"hello":true}`)
+		res = sendRouteRequest(http.MethodPost, "/_liege/routes", `{"id":"hello","path":"/runtime/hello"}`)
+		if res.StatusCode != http.StatusConflict {
+			t.Errorf("want status = %d for an existing route, got %d", http.StatusConflict, res.StatusCode)
+		}
+		res = sendRouteRequest(http.MethodPost, "/_liege/routes", `{"path":"runtime"}`)
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("want status = %d for an invalid route, got %d", http.StatusBadRequest, res.StatusCode)
+		}
+	})
+
+	t.Run("e2e/runtime/override", func(t *testing.T) {
+		// A runtime route is evaluated before a file route with the same specificity
+		res := sendRouteRequest(http.MethodPut, "/_liege/routes/item", `{"path":"/items/1","method":"GET","body":"runtime"}`)
+		if res.StatusCode != http.StatusCreated {
+			t.Errorf("want status = %d, got %d", http.StatusCreated, res.StatusCode)
+		}
+		checkRuntimeRoute(t, "/items/1", http.StatusOK, "runtime")
+	})
+
+	t.Run("e2e/runtime/update", func(t *testing.T) {
+		res := sendRouteRequest(http.MethodPut, "/_liege/routes/hello", `{"path":"/runtime/hello","code":202}`)
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("want status = %d, got %d", http.StatusOK, res.StatusCode)
+		}
+		checkRuntimeRoute(t, "/runtime/hello", http.StatusAccepted, "")
+	})
+
+	t.Run("e2e/runtime/refresh", func(t *testing.T) {
+		res, _ := http.Post(fmt.Sprintf("http://localhost:%d/_liege/refresh", port), "", http.NoBody)
+		_ = res.Body.Close()
+		checkRuntimeRoute(t, "/runtime/hello", http.StatusAccepted, "")
+	})
+
+	t.Run("e2e/runtime/delete", func(t *testing.T) {
+		res := sendRouteRequest(http.MethodDelete, "/_liege/routes/item", "")
+		if res.StatusCode != http.StatusNoContent {
+			t.Errorf("want status = %d, got %d", http.StatusNoContent, res.StatusCode)
+		}
+		content, _ := os.ReadFile("data/items/1__GET.json")
+		checkRuntimeRoute(t, "/items/1", http.StatusOK, string(content))
+		res = sendRouteRequest(http.MethodDelete, "/_liege/routes/item", "")
+		if res.StatusCode != http.StatusNotFound {
+			t.Errorf("want status = %d for a missing route, got %d", http.StatusNotFound, res.StatusCode)
+		}
+	})
+
+	t.Run("e2e/runtime/ttl", func(t *testing.T) {
+		res := sendRouteRequest(http.MethodPost, "/_liege/routes", `{"path":"/runtime/ttl","ttl":1}`)
+		if res.StatusCode != http.StatusCreated {
+			t.Errorf("want status = %d, got %d", http.StatusCreated, res.StatusCode)
+		}
+		checkRuntimeRoute(t, "/runtime/ttl", http.StatusOK, "")
+		time.Sleep(1100 * time.Millisecond)
+		checkRuntimeRoute(t, "/runtime/ttl", http.StatusNotFound, "")
+	})
+
+	t.Run("e2e/runtime/delete-all", func(t *testing.T) {
+		res := sendRouteRequest(http.MethodDelete, "/_liege/routes", "")
+		if res.StatusCode != http.StatusNoContent {
+			t.Errorf("want status = %d, got %d", http.StatusNoContent, res.StatusCode)
+		}
+		checkRuntimeRoute(t, "/runtime/hello", http.StatusNotFound, "")
+	})
+}
+
+// sendRouteRequest sends a request with a JSON body to a management endpoint.
+func sendRouteRequest(method string, path string, body string) *http.Response {
+	req, _ := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", port, path), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	res, _ := http.DefaultClient.Do(req)
+	_ = res.Body.Close()
+	return res
+}
+
+// checkRuntimeRoute requests the given path and checks the response status and body.
+func checkRuntimeRoute(t *testing.T, path string, wantStatus int, wantBody string) {
+	res, _ := http.Get(fmt.Sprintf("http://localhost:%d%s", port, path))
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if res.StatusCode != wantStatus || string(body) != wantBody {
+		t.Errorf("want %s to respond %d '%s', got %d '%s'", path, wantStatus, wantBody, res.StatusCode, body)
+	}
+}
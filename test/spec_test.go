@@ -0,0 +1,64 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"gaelgirodon.fr/liege/internal/model"
+	"gaelgirodon.fr/liege/internal/server"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// testSpecValidation tests request validation against an OpenAPI specification.
+func testSpecValidation(t *testing.T) {
+	const spec = "spec/openapi.json"
+	startServer(&server.StubServer{Config: model.Config{Root: root, Port: port + 1,
+		Spec: spec, SpecMode: model.RejectViolations}})
+	startServer(&server.StubServer{Config: model.Config{Root: root, Port: port + 2,
+		Spec: spec, SpecMode: model.ReportViolations}})
+
+	t.Run("e2e/spec/valid", func(t *testing.T) {
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/items/1", port+1))
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK || len(res.Header.Get("X-Liege-Violations")) > 0 {
+			t.Errorf("want status = %d without violations, got %d", http.StatusOK, res.StatusCode)
+		}
+	})
+
+	t.Run("e2e/spec/undefined", func(t *testing.T) {
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/assets/script.js", port+1))
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("want operations not defined by the specification to be served, got %d", res.StatusCode)
+		}
+	})
+
+	t.Run("e2e/spec/reject", func(t *testing.T) {
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/items/index", port+1))
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("want status = %d, got %d", http.StatusBadRequest, res.StatusCode)
+		}
+		if ct := res.Header.Get("Content-Type"); ct != "application/problem+json" {
+			t.Errorf("want problem content type, got %s", ct)
+		}
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		var problem struct{ Violations []string }
+		_ = json.Unmarshal(body, &problem)
+		if len(problem.Violations) != 1 || problem.Violations[0] != "path parameter id must be of type integer" {
+			t.Errorf("want a path parameter violation, got %s", body)
+		}
+	})
+
+	t.Run("e2e/spec/report", func(t *testing.T) {
+		res, _ := http.Get(fmt.Sprintf("http://localhost:%d/items/index", port+2))
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("want status = %d, got %d", http.StatusOK, res.StatusCode)
+		}
+		if v := res.Header.Get("X-Liege-Violations"); v != "path parameter id must be of type integer" {
+			t.Errorf("want violations to be reported in a header, got %q", v)
+		}
+	})
+}
@@ -97,3 +97,27 @@ func testStub(t *testing.T) {
 		})
 	}
 }
+
+// testRateLimitedStub tests a rate limited route created from a test stub file.
+func testRateLimitedStub(t *testing.T) {
+	url := fmt.Sprintf("http://localhost:%d/limited", port)
+	for i, wantStatus := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		t.Run(fmt.Sprintf("e2e/ratelimit/get/%d/%d", wantStatus, i+1), func(t *testing.T) {
+			res, _ := http.Get(url)
+			_ = res.Body.Close()
+			if res.StatusCode != wantStatus {
+				t.Errorf("want status = %d, got %d", wantStatus, res.StatusCode)
+			}
+			if limit := res.Header.Get("RateLimit-Limit"); limit != "2" {
+				t.Errorf("want RateLimit-Limit = 2, got %q", limit)
+			}
+			wantRemaining := fmt.Sprint(max(1-i, 0))
+			if remaining := res.Header.Get("RateLimit-Remaining"); remaining != wantRemaining {
+				t.Errorf("want RateLimit-Remaining = %s, got %q", wantRemaining, remaining)
+			}
+			if retryAfter := res.Header.Get("Retry-After"); (wantStatus == http.StatusTooManyRequests) != (len(retryAfter) > 0) {
+				t.Errorf("want Retry-After to be set only on 429, got %q", retryAfter)
+			}
+		})
+	}
+}